@@ -1,14 +1,78 @@
 package config
 
+import "time"
+
 type Config struct {
-	AppName string
-	Port    int
-	DBURI   string
-	URL     string
-	JWTKEY  string
-	Email   EmailConfig
-	Phone   PhoneConfig
-	Redis   RedisConfig // 🔥 add this
+	AppName      string
+	Port         int
+	DBURI        string
+	URL          string
+	FrontendURL  string // the web app, links in emails open pages here and it calls the api
+	JWT          JWTConfig
+	Email        EmailConfig
+	Phone        PhoneConfig
+	Redis        RedisConfig // 🔥 add this
+	OIDC         OIDCConfig
+	Passwords    PasswordConfig
+	Passwordless PasswordlessConfig
+	Scheduler    SchedulerConfig
+	Tickets      TicketConfig
+	Geo          GeoConfig
+}
+
+// GeoConfig picks the geocoder that turns a location into coordinates
+type GeoConfig struct {
+	Geocoder      string // "gazetteer" or "none"
+	GazetteerFile string // name,lat,lng,kind rows, kind is locality or city
+}
+
+// TicketConfig is where the ticket signing master key lives, keep it out of git like the jwt keys
+type TicketConfig struct {
+	MasterKeyFile string
+}
+
+// SchedulerConfig is the in process job that completes events and functions once they're over
+type SchedulerConfig struct {
+	Enabled  bool
+	Interval time.Duration
+}
+
+// PasswordlessConfig turns on the sign in options that skip the password
+type PasswordlessConfig struct {
+	MagicLink bool // one time login link by email
+	PhoneOTP  bool // one time code by sms to a verified phone
+}
+
+// PasswordConfig is the policy every new password must pass.
+// HistorySize is how many recent passwords (current one included) can't be reused
+type PasswordConfig struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	BlocklistFile string // one common password per line, matched case insensitive
+	HistorySize   int
+}
+
+// OIDCConfig is the company identity provider for "sign in with SSO"
+type OIDCConfig struct {
+	Enabled      bool
+	Issuer       string
+	ClientID     string
+	ClientSecret string // empty for public clients, PKCE is always used
+	RedirectURL  string
+	Scopes       []string
+	// create an account on the first sso login when no account has the email, off means sign up first
+	AutoProvision bool
+}
+
+// JWTConfig points at the asymmetric signing keys, every <kid>.pem in KeysDir is loaded.
+// ActiveKid signs new tokens, the rest only verify until they are listed in RetiredKids
+type JWTConfig struct {
+	KeysDir     string
+	ActiveKid   string
+	RetiredKids []string
 }
 
 type EmailConfig struct {
@@ -20,6 +84,8 @@ type PhoneConfig struct {
 	Sid   string
 	Token string
 	Phone string
+	// FakeSink keeps sms in memory instead of calling twilio, for local testing
+	FakeSink bool
 }
 
 type RedisConfig struct { // 🔥 add this
@@ -29,23 +95,62 @@ type RedisConfig struct { // 🔥 add this
 }
 
 var AppConfig = &Config{
-	AppName: "Event_Booking",
-	Port:    4040,
-	DBURI:   "your mongodb url",
-	URL:     "http://localhost:4040",
-	JWTKEY:  "your_jwt_secret_here",
+	AppName:     "Event_Booking",
+	Port:        4040,
+	DBURI:       "your mongodb url",
+	URL:         "http://localhost:4040",
+	FrontendURL: "http://localhost:3000",
+	JWT: JWTConfig{
+		KeysDir:     "keys",
+		ActiveKid:   "key-1",
+		RetiredKids: []string{},
+	},
 	Email: EmailConfig{
 		User: "your gmail id",
 		Pass: "Your google app pass",
 	},
 	Phone: PhoneConfig{
-		Sid:   "your_twilio_sid_here",
-		Token: "your_twilio_token_here",
-		Phone: "+1234567890",
+		Sid:      "your_twilio_sid_here",
+		Token:    "your_twilio_token_here",
+		Phone:    "+1234567890",
+		FakeSink: false,
 	},
 	Redis: RedisConfig{ // 🔥 add this
 		Host:     "localhost:6379",
 		Password: "",
 		DB:       0,
 	},
+	OIDC: OIDCConfig{
+		Enabled:       false,
+		Issuer:        "http://localhost:9999",
+		ClientID:      "ivents",
+		ClientSecret:  "",
+		RedirectURL:   "http://localhost:4040/api/public/users/oidc/callback",
+		Scopes:        []string{"openid", "email", "profile"},
+		AutoProvision: false,
+	},
+	Passwords: PasswordConfig{
+		MinLength:     10,
+		RequireUpper:  true,
+		RequireLower:  true,
+		RequireDigit:  true,
+		RequireSymbol: false,
+		BlocklistFile: "config/common-passwords.txt",
+		HistorySize:   5,
+	},
+	Passwordless: PasswordlessConfig{
+		MagicLink: true,
+		PhoneOTP:  false,
+	},
+	Scheduler: SchedulerConfig{
+		Enabled:  true,
+		Interval: time.Minute,
+	},
+	Tickets: TicketConfig{
+		MasterKeyFile: "keys/tickets.key",
+	},
+	Geo: GeoConfig{
+		Geocoder:      "gazetteer",
+		GazetteerFile: "config/gazetteer.csv",
+	},
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/middleware"
 	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
 	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
 	"github.com/gin-gonic/gin"
@@ -22,6 +23,9 @@ var adminCollection *mongo.Collection
 
 func AdminAccessCollect() {
 	adminCollection = utils.MongoClient.Database("Event_Booking").Collection("admin")
+	UserCollection = utils.MongoClient.Database("Event_Booking").Collection("user")
+	EventCollection = utils.MongoClient.Database("Event_Booking").Collection("events")
+	FunctionCollection = utils.MongoClient.Database("Event_Booking").Collection("functions")
 }
 
 // ✅ GET ALL USERS
@@ -153,40 +157,99 @@ func GetOneFunctionAdmin(c *gin.Context) {
 	c.JSON(200, gin.H{"msg": "One Function", "function": oneFunction})
 }
 
-// Admin logout
-func AdminLogout(c *gin.Context) {
+// suspend a user, kicks out every session and access token of them
+func SuspendUser(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	type LogoutInput struct {
-		RefreshToken string `json:"refreshToken"`
+	paramId := c.Param("id")
+	mongoId, err := primitive.ObjectIDFromHex(paramId)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "Invalid id format"})
+		return
 	}
 
-	var input LogoutInput
-	if err := c.ShouldBindJSON(&input); err != nil || input.RefreshToken == "" {
-		c.JSON(400, gin.H{"msg": "Invalid request"})
+	res, err := userCollection.UpdateByID(ctx, mongoId, bson.M{
+		"$set": bson.M{
+			"suspended":  true,
+			"updated_at": time.Now(),
+		},
+	})
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
 		return
 	}
+	if res.MatchedCount == 0 {
+		c.JSON(404, gin.H{"msg": "No such user found"})
+		return
+	}
+
+	if err := utils.RevokeAccountAccess(ctx, sessionCollection, mongoId, "user"); err != nil {
+		c.JSON(500, gin.H{"msg": "user suspended but couldn't revoke sessions"})
+		return
+	}
+
+	c.JSON(200, gin.H{"msg": "User Suspended⚠️"})
+}
+
+// lift a suspension
+func UnsuspendUser(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-	// Find admin by refresh token
-	var admin models.Admin
-	err := adminCollection.FindOne(ctx, bson.M{"refreshToken": input.RefreshToken}).Decode(&admin)
+	paramId := c.Param("id")
+	mongoId, err := primitive.ObjectIDFromHex(paramId)
 	if err != nil {
-		c.JSON(401, gin.H{"msg": "Invalid refresh token"})
+		c.JSON(400, gin.H{"msg": "Invalid id format"})
 		return
 	}
 
-	// Invalidate refresh token
-	_, err = adminCollection.UpdateByID(ctx, admin.ID, bson.M{
+	res, err := userCollection.UpdateByID(ctx, mongoId, bson.M{
 		"$set": bson.M{
-			"refreshToken":  "",
-			"refreshExpiry": time.Time{},
-			"updated_at":    time.Now(),
+			"suspended":  false,
+			"updated_at": time.Now(),
 		},
 	})
-
 	if err != nil {
-		c.JSON(500, gin.H{"msg": "Could not logout, try again"})
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(404, gin.H{"msg": "No such user found"})
+		return
+	}
+
+	c.JSON(200, gin.H{"msg": "User Unsuspended✅"})
+}
+
+// unlock an account locked by too many failed sign ins
+func UnlockAccount(c *gin.Context) {
+	type UnlockInput struct {
+		Email       string `json:"email"`
+		AccountType string `json:"accountType"`
+	}
+
+	var input UnlockInput
+	if err := c.ShouldBindJSON(&input); err != nil || input.Email == "" {
+		c.JSON(400, gin.H{"msg": "Invalid request"})
+		return
+	}
+	if input.AccountType != "admin" {
+		input.AccountType = "user"
+	}
+	middleware.SetAuditTarget(c, input.AccountType, input.Email)
+
+	if err := utils.UnlockAccount(utils.LoginAccountKey(input.AccountType, input.Email)); err != nil {
+		c.JSON(500, gin.H{"msg": "redis error"})
+		return
+	}
+
+	c.JSON(200, gin.H{"msg": "Account Unlocked✅"})
+}
+
+// Admin logout, only the current device is logged out
+func AdminLogout(c *gin.Context) {
+	if !logoutCurrentSession(c) {
 		return
 	}
 
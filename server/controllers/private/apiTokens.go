@@ -0,0 +1,195 @@
+package private
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var apiTokenCollection *mongo.Collection
+
+func APITokenAccessCollect() {
+	apiTokenCollection = utils.MongoClient.Database("Event_Booking").Collection("apiTokens")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// every request with a token looks it up by hash, listing and revoking go by owner
+	_, err := apiTokenCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tokenHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+	})
+	if err != nil {
+		fmt.Println("⚠️ couldn't create api token indexes", err)
+	}
+}
+
+// token lifetime, default and max
+const (
+	defaultAPITokenTTL = 30 * 24 * time.Hour
+	maxAPITokenTTL     = 365 * 24 * time.Hour
+	maxAPITokens       = 20
+)
+
+// create a personal access token, the raw token is only in this response
+func CreateAPIToken(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userId := c.MustGet("userId").(primitive.ObjectID)
+
+	// tokens are looked up against user accounts only
+	if accountType(c) != "user" {
+		c.JSON(403, gin.H{"msg": "Api tokens are only for user accounts"})
+		return
+	}
+
+	type APITokenInput struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expiresInDays"`
+	}
+
+	var input APITokenInput
+	if err := c.ShouldBindJSON(&input); err != nil || strings.TrimSpace(input.Name) == "" || len(input.Scopes) == 0 {
+		c.JSON(400, gin.H{"msg": "Name and at least one scope are required"})
+		return
+	}
+
+	// only known scopes, no duplicates
+	var scopes []string
+	for _, s := range input.Scopes {
+		if !validScope(s) {
+			c.JSON(400, gin.H{"msg": "Unknown scope: " + s, "allowedScopes": models.APITokenScopes})
+			return
+		}
+		dup := false
+		for _, have := range scopes {
+			if have == s {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			scopes = append(scopes, s)
+		}
+	}
+
+	ttl := defaultAPITokenTTL
+	if input.ExpiresInDays > 0 {
+		ttl = time.Duration(input.ExpiresInDays) * 24 * time.Hour
+	}
+	if ttl > maxAPITokenTTL {
+		c.JSON(400, gin.H{"msg": "Api tokens can last at most 365 days"})
+		return
+	}
+
+	count, err := apiTokenCollection.CountDocuments(ctx, bson.M{
+		"userId":    userId,
+		"revoked":   false,
+		"expiresAt": bson.M{"$gt": time.Now()},
+	})
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+	if count >= maxAPITokens {
+		c.JSON(400, gin.H{"msg": "Too many active api tokens, revoke one first"})
+		return
+	}
+
+	raw := utils.GenerateAPIToken()
+
+	var apiToken models.APIToken
+	apiToken.ID = primitive.NewObjectID()
+	apiToken.UserId = userId
+	apiToken.Name = strings.TrimSpace(input.Name)
+	apiToken.Scopes = scopes
+	apiToken.TokenHash = utils.HashToken(raw)
+	apiToken.Prefix = raw[:len(utils.APITokenPrefix)+8]
+	apiToken.ExpiresAt = time.Now().Add(ttl)
+	apiToken.CreatedAt = time.Now()
+
+	_, err = apiTokenCollection.InsertOne(ctx, apiToken)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"msg":      "Api Token Created✅, copy it now, it won't be shown again",
+		"apiToken": apiToken,
+		"token":    raw,
+	})
+}
+
+// list my api tokens, newest first
+func GetAPITokens(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userId := c.MustGet("userId").(primitive.ObjectID)
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := apiTokenCollection.Find(ctx, bson.M{"userId": userId}, opts)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var apiTokens []models.APIToken
+	if err := cursor.All(ctx, &apiTokens); err != nil {
+		c.JSON(400, gin.H{"msg": "db decode error"})
+		return
+	}
+
+	c.JSON(200, gin.H{"msg": "Your Api Tokens", "apiTokens": apiTokens})
+}
+
+// revoke one of my api tokens
+func RevokeAPIToken(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userId := c.MustGet("userId").(primitive.ObjectID)
+
+	paramId := c.Param("id")
+	mongoId, err := primitive.ObjectIDFromHex(paramId)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "Invalid id format"})
+		return
+	}
+
+	res, err := apiTokenCollection.UpdateOne(ctx, bson.M{"_id": mongoId, "userId": userId, "revoked": false}, bson.M{
+		"$set": bson.M{"revoked": true},
+	})
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(404, gin.H{"msg": "No active api token found"})
+		return
+	}
+
+	c.JSON(200, gin.H{"msg": "Api Token Revoked✅"})
+}
+
+func validScope(scope string) bool {
+	for _, s := range models.APITokenScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package private
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var auditCollection *mongo.Collection
+
+func AuditAccessCollect() {
+	auditCollection = utils.MongoClient.Database("Event_Booking").Collection("audit")
+}
+
+// biggest page the query api hands out
+const maxAuditPageSize = 100
+
+// auditFilter builds the mongo filter from the query string, shared by the list and the export.
+// action ending in "*" matches a prefix, e.g. action=admin.*
+func auditFilter(c *gin.Context) (bson.M, error) {
+	filter := bson.M{}
+
+	if action := c.Query("action"); action != "" {
+		if strings.HasSuffix(action, "*") {
+			filter["action"] = bson.M{"$regex": "^" + regexp.QuoteMeta(strings.TrimSuffix(action, "*"))}
+		} else {
+			filter["action"] = action
+		}
+	}
+	if v := c.Query("actorId"); v != "" {
+		filter["actor.id"] = v
+	}
+	if v := c.Query("actorType"); v != "" {
+		filter["actor.type"] = v
+	}
+	if v := c.Query("actorEmail"); v != "" {
+		filter["actor.email"] = v
+	}
+	if v := c.Query("targetId"); v != "" {
+		filter["target.id"] = v
+	}
+	if v := c.Query("outcome"); v != "" {
+		filter["outcome"] = v
+	}
+	if v := c.Query("ip"); v != "" {
+		filter["ip"] = v
+	}
+
+	timeRange := bson.M{}
+	if v := c.Query("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("from must be an RFC3339 time")
+		}
+		timeRange["$gte"] = from
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("to must be an RFC3339 time")
+		}
+		timeRange["$lt"] = to
+	}
+	if len(timeRange) > 0 {
+		filter["time"] = timeRange
+	}
+
+	return filter, nil
+}
+
+// audit log query api, newest first
+func GetAuditLog(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter, err := auditFilter(c)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": err.Error()})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > maxAuditPageSize {
+		limit = 50
+	}
+	skip := (page - 1) * limit
+
+	total, err := auditCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		c.JSON(500, gin.H{"msg": "failed to count audit entries"})
+		return
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "time", Value: -1}}).SetSkip(int64(skip)).SetLimit(int64(limit))
+	cursor, err := auditCollection.Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	entries := []models.AuditEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		c.JSON(400, gin.H{"msg": "decoding error"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"msg":     "Audit Log",
+		"entries": entries,
+		"page":    page,
+		"limit":   limit,
+		"total":   total,
+		"hasNext": int64(skip+limit) < total,
+		"hasPrev": page > 1,
+	})
+}
+
+// audit log export, same filters as the query api, one json object per line (oldest first)
+func ExportAuditLog(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	filter, err := auditFilter(c)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": err.Error()})
+		return
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "time", Value: 1}}).SetBatchSize(500)
+	cursor, err := auditCollection.Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="audit-%s.jsonl"`, time.Now().Format("20060102-150405")))
+	c.Status(200)
+
+	// stream it, an export can be much bigger than we want in memory
+	enc := json.NewEncoder(c.Writer)
+	for cursor.Next(ctx) {
+		var entry models.AuditEntry
+		if err := cursor.Decode(&entry); err != nil {
+			continue
+		}
+		if err := enc.Encode(entry); err != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+}
@@ -0,0 +1,537 @@
+package private
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/config"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var bookingCollection *mongo.Collection
+
+// most invitees added in one request
+const maxInviteesPerRequest = 100
+
+func BookingsCollect() {
+	bookingCollection = utils.MongoClient.Database("Event_Booking").Collection("bookings")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := bookingCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		// one live booking per user and event, two parallel RSVPs can't both get in
+		{
+			Keys:    bson.D{{Key: "eventId", Value: 1}, {Key: "userId", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"active": true}),
+		},
+		// the waitlist, in order
+		{Keys: bson.D{{Key: "eventId", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "created_at", Value: -1}}},
+		// ticket ids are what check-in looks up
+		{
+			Keys:    bson.D{{Key: "ticketId", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"ticketId": bson.M{"$exists": true}}),
+		},
+	})
+	if err != nil {
+		fmt.Println("⚠️ couldn't create booking indexes", err)
+	}
+}
+
+// RSVP to an event, confirmed while seats last, waitlisted after that
+func BookEvent(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userId := c.MustGet("userId").(primitive.ObjectID)
+	eventId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "Invalid param Id"})
+		return
+	}
+
+	var event models.Event
+	if err := eventsCollection.FindOne(ctx, bson.M{"_id": eventId}).Decode(&event); err != nil {
+		c.JSON(404, gin.H{"msg": "No event found ❌"})
+		return
+	}
+	if !canRSVP(&event, userId) {
+		c.JSON(404, gin.H{"msg": "No event found ❌"})
+		return
+	}
+	endAt := event.EndAt
+	if event.Recurrence != nil {
+		endAt = event.Recurrence.EndAt
+	}
+	if event.Status != models.StatusUpcoming || (!endAt.IsZero() && endAt.Before(time.Now())) {
+		c.JSON(409, gin.H{"msg": "This event isn't taking RSVPs anymore"})
+		return
+	}
+
+	type BookInput struct {
+		TicketType string `json:"ticketType" form:"ticketType"`
+	}
+	var input BookInput
+	_ = c.ShouldBind(&input)
+	if msg := checkTicketType(&event, input.TicketType); msg != "" {
+		c.JSON(400, gin.H{"msg": msg})
+		return
+	}
+
+	var existing models.Booking
+	err = bookingCollection.FindOne(ctx, bson.M{"eventId": eventId, "userId": userId, "active": true}).Decode(&existing)
+	if err == nil {
+		c.JSON(409, gin.H{"msg": "You already RSVP'd to this event", "booking": existing})
+		return
+	}
+
+	// take a seat first, the counter is what keeps us under capacity
+	seated, err := reserveSeat(ctx, eventId, input.TicketType)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+
+	var booking models.Booking
+	booking.ID = primitive.NewObjectID()
+	booking.EventId = eventId
+	booking.UserId = userId
+	booking.Status = models.BookingWaitlisted
+	booking.Active = true
+	booking.TicketType = input.TicketType
+	booking.CreatedAt = time.Now()
+	booking.UpdatedAt = time.Now()
+	if seated {
+		booking.Status = models.BookingConfirmed
+		booking.ConfirmedAt = time.Now()
+		booking.TicketId = utils.NewTicketId()
+	}
+
+	if _, err := bookingCollection.InsertOne(ctx, booking); err != nil {
+		if seated {
+			releaseSeat(ctx, eventId, input.TicketType)
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(409, gin.H{"msg": "You already RSVP'd to this event"})
+			return
+		}
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+
+	// a seat may have freed up between our check and the insert
+	if !seated {
+		promoteWaitlist(ctx, eventId, input.TicketType)
+		_ = bookingCollection.FindOne(ctx, bson.M{"_id": booking.ID}).Decode(&booking)
+	}
+
+	if booking.Status == models.BookingConfirmed {
+		c.JSON(200, gin.H{"msg": "You're in! RSVP confirmed🎉", "booking": booking})
+		return
+	}
+
+	position, _ := waitlistPosition(ctx, &booking)
+	c.JSON(200, gin.H{"msg": "Event is full, you're on the waitlist⏳", "booking": booking, "position": position})
+}
+
+// cancel my RSVP, a confirmed seat goes to the first one waiting
+func CancelBooking(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userId := c.MustGet("userId").(primitive.ObjectID)
+	eventId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "Invalid param Id"})
+		return
+	}
+
+	var before models.Booking
+	err = bookingCollection.FindOneAndUpdate(ctx, bson.M{"eventId": eventId, "userId": userId, "active": true}, bson.M{
+		"$set": bson.M{
+			"status":      models.BookingCancelled,
+			"active":      false,
+			"cancelledAt": time.Now(),
+			"updated_at":  time.Now(),
+		},
+	}).Decode(&before)
+	if err != nil {
+		c.JSON(404, gin.H{"msg": "You have no RSVP for this event"})
+		return
+	}
+
+	if before.Status == models.BookingConfirmed {
+		releaseSeat(ctx, eventId, before.TicketType)
+		promoteWaitlist(ctx, eventId, before.TicketType)
+	}
+
+	c.JSON(200, gin.H{"msg": "RSVP cancelled✅"})
+}
+
+// my RSVP for one event, with the waitlist position
+func GetMyBooking(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userId := c.MustGet("userId").(primitive.ObjectID)
+	eventId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "Invalid param Id"})
+		return
+	}
+
+	var booking models.Booking
+	err = bookingCollection.FindOne(ctx, bson.M{"eventId": eventId, "userId": userId, "active": true}).Decode(&booking)
+	if err != nil {
+		c.JSON(404, gin.H{"msg": "You have no RSVP for this event"})
+		return
+	}
+
+	response := gin.H{"msg": "Your RSVP✨", "booking": booking}
+	if booking.Status == models.BookingWaitlisted {
+		position, _ := waitlistPosition(ctx, &booking)
+		response["position"] = position
+	}
+	c.JSON(200, response)
+}
+
+// all my RSVPs, newest first. ?status= narrows it down
+func GetMyBookings(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userId := c.MustGet("userId").(primitive.ObjectID)
+
+	filter := bson.M{"userId": userId}
+	if status := c.Query("status"); status != "" {
+		filter["status"] = status
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(100)
+	cursor, err := bookingCollection.Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	bookings := []models.Booking{}
+	if err := cursor.All(ctx, &bookings); err != nil {
+		c.JSON(400, gin.H{"msg": "decoding error"})
+		return
+	}
+
+	c.JSON(200, gin.H{"msg": "Your RSVPs✨", "bookings": bookings})
+}
+
+// the organiser's view: confirmed guests and the waitlist in order
+func GetEventBookings(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userId := c.MustGet("userId").(primitive.ObjectID)
+	eventId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "Invalid param Id"})
+		return
+	}
+
+	var event models.Event
+	if err := eventsCollection.FindOne(ctx, bson.M{"_id": eventId, "userId": userId}).Decode(&event); err != nil {
+		c.JSON(404, gin.H{"msg": "No event found ❌"})
+		return
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := bookingCollection.Find(ctx, bson.M{"eventId": eventId, "active": true}, opts)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var bookings []models.Booking
+	if err := cursor.All(ctx, &bookings); err != nil {
+		c.JSON(400, gin.H{"msg": "decoding error"})
+		return
+	}
+
+	confirmed := []models.Booking{}
+	waitlist := []models.Booking{}
+	for _, b := range bookings {
+		if b.Status == models.BookingConfirmed {
+			confirmed = append(confirmed, b)
+		} else {
+			waitlist = append(waitlist, b)
+		}
+	}
+
+	c.JSON(200, gin.H{
+		"msg":       "Event RSVPs✨",
+		"capacity":  event.EventAttendence,
+		"confirmed": confirmed,
+		"waitlist":  waitlist,
+	})
+}
+
+// let users RSVP to my private event
+func AddEventInvitees(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userId := c.MustGet("userId").(primitive.ObjectID)
+	eventId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "Invalid param Id"})
+		return
+	}
+
+	type InviteesInput struct {
+		UserIds []string `json:"userIds"`
+	}
+
+	var input InviteesInput
+	if err := c.ShouldBindJSON(&input); err != nil || len(input.UserIds) == 0 || len(input.UserIds) > maxInviteesPerRequest {
+		c.JSON(400, gin.H{"msg": fmt.Sprintf("send 1 to %d userIds", maxInviteesPerRequest)})
+		return
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(input.UserIds))
+	for _, hex := range input.UserIds {
+		id, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			c.JSON(400, gin.H{"msg": "Invalid user id " + hex})
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	res, err := eventsCollection.UpdateOne(ctx, bson.M{"_id": eventId, "userId": userId}, bson.M{
+		"$addToSet": bson.M{"invitedUsers": bson.M{"$each": ids}},
+		"$set":      bson.M{"updated_at": time.Now()},
+	})
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(404, gin.H{"msg": "No event found ❌"})
+		return
+	}
+
+	c.JSON(200, gin.H{"msg": "Invitees added✅"})
+}
+
+// take an invite back, an RSVP they already made stays
+func RemoveEventInvitee(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userId := c.MustGet("userId").(primitive.ObjectID)
+	eventId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "Invalid param Id"})
+		return
+	}
+	inviteeId, err := primitive.ObjectIDFromHex(c.Param("userId"))
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "Invalid user id"})
+		return
+	}
+
+	res, err := eventsCollection.UpdateOne(ctx, bson.M{"_id": eventId, "userId": userId}, bson.M{
+		"$pull": bson.M{"invitedUsers": inviteeId},
+		"$set":  bson.M{"updated_at": time.Now()},
+	})
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(404, gin.H{"msg": "No event found ❌"})
+		return
+	}
+
+	c.JSON(200, gin.H{"msg": "Invitee removed✅"})
+}
+
+// canRSVP: public events are open to everyone, private ones to the organiser and invitees
+func canRSVP(event *models.Event, userId primitive.ObjectID) bool {
+	if event.IsPublic == "public" || event.UserId == userId {
+		return true
+	}
+	for _, id := range event.InvitedUsers {
+		if id == userId {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTicketType: events with ticket types need one that's still on sale, the rest take none
+func checkTicketType(event *models.Event, ticketType string) string {
+	if len(event.TicketTypes) == 0 {
+		if ticketType != "" {
+			return "This event has no ticket types"
+		}
+		return ""
+	}
+	for _, t := range event.TicketTypes {
+		if t.Name != ticketType {
+			continue
+		}
+		if !t.SalesEndAt.IsZero() && t.SalesEndAt.Before(time.Now()) {
+			return ticketType + " tickets are no longer on sale"
+		}
+		return ""
+	}
+	return "Pick a ticketType for this event"
+}
+
+// reserveSeat takes one seat (and one ticket of the type) if the event still has one,
+// atomically on the event document
+func reserveSeat(ctx context.Context, eventId primitive.ObjectID, ticketType string) (bool, error) {
+	filter := bson.M{
+		"_id":    eventId,
+		"status": models.StatusUpcoming,
+		"$expr":  bson.M{"$lt": bson.A{bson.M{"$ifNull": bson.A{"$confirmedCount", 0}}, "$attendence"}},
+	}
+	update := bson.M{"$inc": bson.M{"confirmedCount": 1}}
+	if ticketType != "" {
+		filter["ticketTypes"] = bson.M{"$elemMatch": bson.M{"name": ticketType, "remaining": bson.M{"$gt": 0}}}
+		update["$inc"] = bson.M{"confirmedCount": 1, "ticketTypes.$.remaining": -1}
+	}
+
+	res, err := eventsCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+func releaseSeat(ctx context.Context, eventId primitive.ObjectID, ticketType string) {
+	filter := bson.M{"_id": eventId, "confirmedCount": bson.M{"$gt": 0}}
+	update := bson.M{"$inc": bson.M{"confirmedCount": -1}}
+	if ticketType != "" {
+		filter["ticketTypes.name"] = ticketType
+		update["$inc"] = bson.M{"confirmedCount": -1, "ticketTypes.$.remaining": 1}
+	}
+	_, _ = eventsCollection.UpdateOne(ctx, filter, update)
+}
+
+// promoteWaitlist hands free seats to the waitlist of one ticket type in order. every promotion
+// takes a seat first, so racing cancels and RSVPs can leave a seat briefly unused but never overbook
+func promoteWaitlist(ctx context.Context, eventId primitive.ObjectID, ticketType string) {
+	for {
+		seated, err := reserveSeat(ctx, eventId, ticketType)
+		if err != nil || !seated {
+			return
+		}
+
+		var promoted models.Booking
+		opts := options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+			SetReturnDocument(options.After)
+		err = bookingCollection.FindOneAndUpdate(ctx, bson.M{
+			"eventId":    eventId,
+			"ticketType": ticketType,
+			"status":     models.BookingWaitlisted,
+			"active":     true,
+		}, bson.M{"$set": bson.M{
+			"status":      models.BookingConfirmed,
+			"ticketId":    utils.NewTicketId(),
+			"confirmedAt": time.Now(),
+			"updated_at":  time.Now(),
+		}}, opts).Decode(&promoted)
+		if err != nil {
+			// nobody waiting (or db trouble), give the seat back
+			releaseSeat(ctx, eventId, ticketType)
+			return
+		}
+
+		notifyPromoted(promoted)
+	}
+}
+
+// promoteAllWaitlists runs the waitlist of every ticket type, after capacity or quotas change
+func promoteAllWaitlists(ctx context.Context, eventId primitive.ObjectID) {
+	var event models.Event
+	opts := options.FindOne().SetProjection(bson.M{"ticketTypes": 1})
+	if err := eventsCollection.FindOne(ctx, bson.M{"_id": eventId}, opts).Decode(&event); err != nil {
+		return
+	}
+	promoteWaitlist(ctx, eventId, "")
+	for _, t := range event.TicketTypes {
+		promoteWaitlist(ctx, eventId, t.Name)
+	}
+}
+
+// notifyPromoted tells a waitlisted user they got a seat
+func notifyPromoted(booking models.Booking) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var user models.User
+		if err := UserCollection.FindOne(ctx, bson.M{"_id": booking.UserId}).Decode(&user); err != nil {
+			return
+		}
+		var event models.Event
+		if err := eventsCollection.FindOne(ctx, bson.M{"_id": booking.EventId}).Decode(&event); err != nil {
+			return
+		}
+
+		emailData := utils.EmailData{
+			From:    "Team Ivents Plannerz🎉",
+			To:      user.Email,
+			Subject: "You're off the waitlist!",
+			Html: fmt.Sprintf(`<h2>A seat opened up for %s</h2><p>Your RSVP is now confirmed. Can't make it? <a href="%s/events/%s">Cancel your RSVP</a> so the next person gets the seat.</p>`,
+				event.EventName, config.AppConfig.URL, event.ID.Hex()),
+		}
+		_ = utils.SendEmail(emailData)
+	}()
+}
+
+// waitlistPosition is 1 for the next in line
+func waitlistPosition(ctx context.Context, booking *models.Booking) (int64, error) {
+	if booking.Status != models.BookingWaitlisted {
+		return 0, errors.New("booking isn't waitlisted")
+	}
+	ahead, err := bookingCollection.CountDocuments(ctx, bson.M{
+		"eventId": booking.EventId,
+		"status":  models.BookingWaitlisted,
+		"active":  true,
+		"$or": bson.A{
+			bson.M{"created_at": bson.M{"$lt": booking.CreatedAt}},
+			bson.M{"created_at": booking.CreatedAt, "_id": bson.M{"$lt": booking.ID}},
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return ahead + 1, nil
+}
+
+// cancelEventBookings closes every live booking of deleted events
+func cancelEventBookings(ctx context.Context, eventIds []primitive.ObjectID) {
+	if len(eventIds) == 0 {
+		return
+	}
+	_, _ = bookingCollection.UpdateMany(ctx, bson.M{"eventId": bson.M{"$in": eventIds}, "active": true}, bson.M{
+		"$set": bson.M{
+			"status":      models.BookingCancelled,
+			"active":      false,
+			"cancelledAt": time.Now(),
+			"updated_at":  time.Now(),
+		},
+	})
+}
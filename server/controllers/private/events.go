@@ -22,6 +22,9 @@ var eventsCollection *mongo.Collection
 
 func EventsCollect() {
 	eventsCollection = utils.MongoClient.Database("Event_Booking").Collection("events")
+	scheduleIndexes(eventsCollection)
+	geoIndexes(eventsCollection)
+	recurrenceIndexes(eventsCollection)
 }
 
 // create even api
@@ -46,8 +49,23 @@ func CreateEvent(c *gin.Context) {
 	}
 	eventDes := c.PostForm("eventdesc")
 	isPublic := c.PostForm("ispublic")
-	status := c.PostForm("status")
 	location := c.PostForm("location")
+	schedule, ok := scheduleFromForm(c, nil)
+	if !ok {
+		return
+	}
+	recurrence, ok := recurrenceFromForm(c, schedule, nil)
+	if !ok {
+		return
+	}
+	coordinates, ok := coordinatesFromForm(c, location, true)
+	if !ok {
+		return
+	}
+	history, ok := initialStatus(c, userId)
+	if !ok {
+		return
+	}
 	imageUrl, err := utils.FileUpload(c)
 	if err != nil {
 		imageUrl = ""
@@ -63,9 +81,15 @@ func CreateEvent(c *gin.Context) {
 	newEvent.EventAttendence = eventAttendenceInt
 	newEvent.EventDescription = eventDes
 	newEvent.IsPublic = isPublic
-	newEvent.Status = status
+	newEvent.Status = models.StatusUpcoming
+	newEvent.StatusHistory = history
 	newEvent.Location = location
 	newEvent.ImageUrl = imageUrl
+	newEvent.StartAt = schedule.StartAt
+	newEvent.EndAt = schedule.EndAt
+	newEvent.Timezone = schedule.Timezone
+	newEvent.Coordinates = coordinates
+	newEvent.Recurrence = recurrence
 	newEvent.CreatedAt = time.Now()
 	newEvent.UpdatedAt = time.Now()
 
@@ -110,8 +134,31 @@ func GetAllEvents(c *gin.Context) {
 	}
 	skip := (page - 1) * limit
 
+	// ---------------- Date range (?from=&to=) ----------------
+	filter := bson.M{"userId": userId}
+	from, to, rangeKey, ok := rangeFromQuery(c, filter)
+	if !ok {
+		return
+	}
+
+	// ---------------- Near me (?near=&radius= or ?bbox=) ----------------
+	geo, err := utils.ParseGeoQuery(c.Query("near"), c.Query("radius"), c.Query("bbox"))
+	if err != nil {
+		c.JSON(400, gin.H{"msg": err.Error()})
+		return
+	}
+	if geo != nil {
+		listNear(ctx, c, eventsCollection, geo, filter, page, limit, "events", "All Events Are here✨", func(e *models.Event, distanceKm float64) {
+			e.DistanceKm = &distanceKm
+			if !from.IsZero() && !to.IsZero() {
+				e.Occurrences = utils.Occurrences(e, from, to)
+			}
+		})
+		return
+	}
+
 	// ---------------- Redis cache check ----------------
-	cacheKey := fmt.Sprintf("events:%s:%d:%d", userId.Hex(), page, limit)
+	cacheKey := fmt.Sprintf("events:%s:%d:%d:%s", userId.Hex(), page, limit, rangeKey)
 	cachedData, err := utils.RedisClient.Get(ctx, cacheKey).Result()
 	if err == nil {
 		var cachedResponse struct {
@@ -133,14 +180,18 @@ func GetAllEvents(c *gin.Context) {
 	}
 
 	// ---------------- DB fallback ----------------
-	total, err := eventsCollection.CountDocuments(ctx, bson.M{"userId": userId})
+	total, err := eventsCollection.CountDocuments(ctx, filter)
 	if err != nil {
 		c.JSON(500, gin.H{"msg": "failed to count events"})
 		return
 	}
 
 	opts := options.Find().SetSkip(int64(skip)).SetLimit(int64(limit))
-	cursor, err := eventsCollection.Find(ctx, bson.M{"userId": userId}, opts)
+	if len(filter) > 1 {
+		// range queries read like a calendar
+		opts.SetSort(bson.D{{Key: "startAt", Value: 1}})
+	}
+	cursor, err := eventsCollection.Find(ctx, filter, opts)
 	if err != nil {
 		c.JSON(400, gin.H{"msg": "db error"})
 		return
@@ -153,6 +204,13 @@ func GetAllEvents(c *gin.Context) {
 		return
 	}
 
+	// ---------------- Occurrences in the window ----------------
+	if !from.IsZero() && !to.IsZero() {
+		for i := range allEvents {
+			allEvents[i].Occurrences = utils.Occurrences(&allEvents[i], from, to)
+		}
+	}
+
 	// ---------------- Prepare response ----------------
 	response := struct {
 		Msg     string         `json:"msg"`
@@ -293,8 +351,21 @@ func EditEventApi(c *gin.Context) {
 	}
 	eventDes := c.PostForm("eventdesc")
 	isPublic := c.PostForm("ispublic")
-	status := c.PostForm("status")
 	location := c.PostForm("location")
+	schedule, ok := scheduleFromForm(c, &utils.Schedule{StartAt: editEvent.StartAt, EndAt: editEvent.EndAt, Timezone: editEvent.Timezone})
+	if !ok {
+		return
+	}
+	// a series keeps its rule and exceptions unless the form changes them, see EditOccurrence for single dates
+	recurrence, ok := recurrenceFromForm(c, schedule, &editEvent)
+	if !ok {
+		return
+	}
+	// a new address is looked up again, the same one keeps its pin
+	coordinates, ok := coordinatesFromForm(c, location, location != editEvent.Location)
+	if !ok {
+		return
+	}
 	imageUrl, err := utils.FileUpload(c)
 	if err != nil {
 		imageUrl = ""
@@ -311,19 +382,51 @@ func EditEventApi(c *gin.Context) {
 			"attendence": eventAttendenceInt,
 			"eventdesc":  eventDes,
 			"ispublic":   isPublic,
-			"status":     status,
 			"location":   location,
 			"imageUrl":   imageUrl,
+			"startAt":    schedule.StartAt,
+			"endAt":      schedule.EndAt,
+			"timezone":   schedule.Timezone,
 			"updated_at": time.Now(),
 		}}
+	unset := bson.M{}
+	if coordinates != nil {
+		update["$set"].(bson.M)["coordinates"] = coordinates
+	} else if location != editEvent.Location {
+		unset["coordinates"] = ""
+	}
+	if recurrence != nil {
+		update["$set"].(bson.M)["recurrence"] = recurrence
+	} else if editEvent.Recurrence != nil {
+		unset["recurrence"] = ""
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	// status goes through the state machine
+	filter := bson.M{"_id": mongoId}
+	if !statusFromForm(c, userId, editEvent.Status, filter, update) {
+		return
+	}
+
 	// update the db
-	_, err = eventsCollection.UpdateByID(ctx, mongoId, update)
+	res, err := eventsCollection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		c.JSON(400, gin.H{
 			"msg": "db error",
 		})
 		return
 	}
+	if res.MatchedCount == 0 {
+		c.JSON(409, gin.H{
+			"msg": "Event status changed meanwhile, reload and try again",
+		})
+		return
+	}
+
+	// more seats => the waitlist moves up
+	promoteAllWaitlists(ctx, mongoId)
 
 	c.JSON(200, gin.H{
 		"msg": "Event Updated Successfully!✅", "UpdatedEvent": editEvent})
@@ -349,13 +452,16 @@ func DeleteOneEvent(c *gin.Context) {
 
 	// find one id and delete
 	// var deleteEvent models.Event
-	_, err = eventsCollection.DeleteOne(ctx, bson.M{"userId": userId, "_id": mongoId})
+	res, err := eventsCollection.DeleteOne(ctx, bson.M{"userId": userId, "_id": mongoId})
 	if err != nil {
 		c.JSON(400, gin.H{
 			"msg": "No Event Found or userid not found",
 		})
 		return
 	}
+	if res.DeletedCount > 0 {
+		cancelEventBookings(ctx, []primitive.ObjectID{mongoId})
+	}
 
 	c.JSON(200, gin.H{
 		"msg": "One Event is deleted✅",
@@ -371,14 +477,27 @@ func DeleteAllEvents(c *gin.Context) {
 	// userid
 	userId := c.MustGet("userId").(primitive.ObjectID)
 
+	// remember the ids so their RSVPs can be closed
+	var eventIds []primitive.ObjectID
+	cursor, err := eventsCollection.Find(ctx, bson.M{"userId": userId}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err == nil {
+		var owned []models.Event
+		if cursor.All(ctx, &owned) == nil {
+			for _, e := range owned {
+				eventIds = append(eventIds, e.ID)
+			}
+		}
+	}
+
 	// find userId and delete all events of it
-	_, err := eventsCollection.DeleteMany(ctx, bson.M{"userId": userId})
+	_, err = eventsCollection.DeleteMany(ctx, bson.M{"userId": userId})
 	if err != nil {
 		c.JSON(400, gin.H{
 			"msg": "DB error",
 		})
 		return
 	}
+	cancelEventBookings(ctx, eventIds)
 
 	c.JSON(200, gin.H{
 		"msg": "All Events Deleted✅",
@@ -20,6 +20,8 @@ var functionCollection *mongo.Collection
 
 func FunctionCollect() {
 	functionCollection = utils.MongoClient.Database("Event_Booking").Collection("functions")
+	scheduleIndexes(functionCollection)
+	geoIndexes(functionCollection)
 }
 
 // Create Function
@@ -33,8 +35,19 @@ func CreateFunction(c *gin.Context) {
 	funcType := c.PostForm("functype")
 	funcDesc := c.PostForm("funcdes")
 	isPublic := c.PostForm("ispublic")
-	status := c.PostForm("status")
 	location := c.PostForm("location")
+	schedule, ok := scheduleFromForm(c, nil)
+	if !ok {
+		return
+	}
+	coordinates, ok := coordinatesFromForm(c, location, true)
+	if !ok {
+		return
+	}
+	history, ok := initialStatus(c, userId)
+	if !ok {
+		return
+	}
 	imageUrl, err := utils.FileUpload(c)
 	if err != nil {
 		imageUrl = ""
@@ -48,8 +61,13 @@ func CreateFunction(c *gin.Context) {
 	newFunction.FuncDesc = funcDesc
 	newFunction.ImageUrl = imageUrl
 	newFunction.IsPublic = isPublic
-	newFunction.Status = status
+	newFunction.Status = models.StatusUpcoming
+	newFunction.StatusHistory = history
 	newFunction.Location = location
+	newFunction.StartAt = schedule.StartAt
+	newFunction.EndAt = schedule.EndAt
+	newFunction.Timezone = schedule.Timezone
+	newFunction.Coordinates = coordinates
 	newFunction.CreatedAt = time.Now()
 	newFunction.UpdatedAt = time.Now()
 
@@ -99,7 +117,27 @@ func GetAllFunctions(c *gin.Context) {
 	}
 	skip := (page - 1) * limit
 
-	cacheKey := fmt.Sprintf("functions:%s:%d:%d", userId.Hex(), page, limit)
+	// ?from=&to= date range
+	filter := bson.M{"userId": userId}
+	_, _, rangeKey, ok := rangeFromQuery(c, filter)
+	if !ok {
+		return
+	}
+
+	// near me, ?near=lat,lng&radius=km or ?bbox=
+	geo, err := utils.ParseGeoQuery(c.Query("near"), c.Query("radius"), c.Query("bbox"))
+	if err != nil {
+		c.JSON(400, gin.H{"msg": err.Error()})
+		return
+	}
+	if geo != nil {
+		listNear(ctx, c, functionCollection, geo, filter, page, limit, "functions", "All Functions are here✨", func(f *models.Function, distanceKm float64) {
+			f.DistanceKm = &distanceKm
+		})
+		return
+	}
+
+	cacheKey := fmt.Sprintf("functions:%s:%d:%d:%s", userId.Hex(), page, limit, rangeKey)
 	if utils.RedisClient != nil {
 		if cached, err := utils.RedisClient.Get(ctx, cacheKey).Result(); err == nil && cached != "" {
 			var funcs []models.Function
@@ -118,9 +156,13 @@ func GetAllFunctions(c *gin.Context) {
 	}
 
 	// DB fallback
-	total, _ := functionCollection.CountDocuments(ctx, bson.M{"userId": userId})
-	opts := options.Find().SetSkip(int64(skip)).SetLimit(int64(limit)).SetSort(bson.D{{Key: "createdAt", Value: -1}})
-	cursor, err := functionCollection.Find(ctx, bson.M{"userId": userId}, opts)
+	total, _ := functionCollection.CountDocuments(ctx, filter)
+	sort := bson.D{{Key: "createdAt", Value: -1}}
+	if len(filter) > 1 {
+		sort = bson.D{{Key: "startAt", Value: 1}}
+	}
+	opts := options.Find().SetSkip(int64(skip)).SetLimit(int64(limit)).SetSort(sort)
+	cursor, err := functionCollection.Find(ctx, filter, opts)
 	if err != nil {
 		c.JSON(400, gin.H{"msg": "db error"})
 		return
@@ -235,8 +277,16 @@ func EditFunction(c *gin.Context) {
 	funcType := c.PostForm("functype")
 	funcDesc := c.PostForm("funcdes")
 	isPublic := c.PostForm("ispublic")
-	status := c.PostForm("status")
 	location := c.PostForm("location")
+	schedule, ok := scheduleFromForm(c, &utils.Schedule{StartAt: oldFunc.StartAt, EndAt: oldFunc.EndAt, Timezone: oldFunc.Timezone})
+	if !ok {
+		return
+	}
+	// a new address is looked up again, the same one keeps its pin
+	coordinates, ok := coordinatesFromForm(c, location, location != oldFunc.Location)
+	if !ok {
+		return
+	}
 	imageUrl, err := utils.FileUpload(c)
 	if err != nil {
 		imageUrl = ""
@@ -248,17 +298,35 @@ func EditFunction(c *gin.Context) {
 			"functype":    funcType,
 			"funcdes":     funcDesc,
 			"ispublic":    isPublic,
-			"status":      status,
 			"location":    location,
 			"imageUrl":    imageUrl,
+			"startAt":     schedule.StartAt,
+			"endAt":       schedule.EndAt,
+			"timezone":    schedule.Timezone,
 			"updated_at":  time.Now(),
 		}}
 
-	_, err = functionCollection.UpdateByID(ctx, oldFunc.ID, update)
+	if coordinates != nil {
+		update["$set"].(bson.M)["coordinates"] = coordinates
+	} else if location != oldFunc.Location {
+		update["$unset"] = bson.M{"coordinates": ""}
+	}
+
+	// status goes through the state machine
+	filter := bson.M{"_id": oldFunc.ID}
+	if !statusFromForm(c, userId, oldFunc.Status, filter, update) {
+		return
+	}
+
+	res, err := functionCollection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		c.JSON(400, gin.H{"msg": "db error"})
 		return
 	}
+	if res.MatchedCount == 0 {
+		c.JSON(409, gin.H{"msg": "Function status changed meanwhile, reload and try again"})
+		return
+	}
 
 	c.JSON(200, gin.H{"msg": "Function Updated Successfully!✅", "updatedFunction": oldFunc})
 }
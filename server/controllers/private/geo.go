@@ -0,0 +1,99 @@
+package private
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// coordinatesFromForm reads the optional lat/lng form fields. without them and with geocode set,
+// the location text is looked up instead. nil means no coordinates, 400 is answered here
+func coordinatesFromForm(c *gin.Context, location string, geocode bool) (*models.GeoPoint, bool) {
+	latStr := c.PostForm("lat")
+	lngStr := c.PostForm("lng")
+
+	if latStr != "" || lngStr != "" {
+		lat, errLat := strconv.ParseFloat(latStr, 64)
+		lng, errLng := strconv.ParseFloat(lngStr, 64)
+		if errLat != nil || errLng != nil || !utils.ValidLatLng(lat, lng) {
+			c.JSON(400, gin.H{"msg": "lat must be -90..90 and lng -180..180, send both"})
+			return nil, false
+		}
+		return models.NewGeoPoint(lat, lng), true
+	}
+
+	if !geocode || location == "" {
+		return nil, true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	lat, lng, err := utils.Geocode(ctx, location)
+	if err != nil {
+		return nil, true
+	}
+	return models.NewGeoPoint(lat, lng), true
+}
+
+// geoIndexes backs ?near= and ?bbox= queries
+func geoIndexes(coll *mongo.Collection) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "coordinates", Value: "2dsphere"}}})
+	if err != nil {
+		fmt.Println("⚠️ couldn't create geo index on", coll.Name(), err)
+	}
+}
+
+// nearDoc is a stored document plus how far it is, in km
+type nearDoc[T any] struct {
+	Doc      T       `bson:",inline"`
+	Distance float64 `bson:"distance"`
+}
+
+// listNear answers a listing with a geo query, nearest first. not cached, every point is different.
+// finish puts the distance, and whatever else the plain listing fills in, on each item
+func listNear[T any](ctx context.Context, c *gin.Context, coll *mongo.Collection, geo *utils.GeoQuery, filter bson.M, page int, limit int, key string, msg string, finish func(item *T, distanceKm float64)) {
+	total, err := coll.CountDocuments(ctx, geo.CountFilter(filter))
+	if err != nil {
+		c.JSON(500, gin.H{"msg": "failed to count " + key})
+		return
+	}
+
+	skip := (page - 1) * limit
+	cursor, err := coll.Aggregate(ctx, geo.Pipeline(filter, int64(skip), int64(limit)))
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	docs := []nearDoc[T]{}
+	if err := cursor.All(ctx, &docs); err != nil {
+		c.JSON(400, gin.H{"msg": "decoding error"})
+		return
+	}
+	items := make([]T, 0, len(docs))
+	for _, d := range docs {
+		finish(&d.Doc, d.Distance)
+		items = append(items, d.Doc)
+	}
+
+	c.JSON(200, gin.H{
+		"msg":     msg,
+		key:       items,
+		"page":    page,
+		"limit":   limit,
+		"total":   total,
+		"hasNext": int64(skip+limit) < total,
+		"hasPrev": page > 1,
+		"source":  "db",
+	})
+}
@@ -0,0 +1,173 @@
+package private
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/middleware"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// start acting as a user, gives a short lived access token (no refresh token) marked with the admin
+func StartImpersonation(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	adminId := c.MustGet("userId").(primitive.ObjectID)
+
+	// admins only, a user token can't start another impersonation
+	if accountType(c) != "admin" || c.GetBool("impersonated") {
+		c.JSON(403, gin.H{"msg": "Only admins can impersonate users"})
+		return
+	}
+
+	paramId := c.Param("id")
+	userId, err := primitive.ObjectIDFromHex(paramId)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "Invalid id format"})
+		return
+	}
+
+	type ImpersonateInput struct {
+		Reason string `json:"reason"`
+	}
+
+	// the reason ends up on the session, so the user and other admins can see why
+	var input ImpersonateInput
+	if err := c.ShouldBindJSON(&input); err != nil || strings.TrimSpace(input.Reason) == "" {
+		c.JSON(400, gin.H{"msg": "A reason is required to impersonate a user"})
+		return
+	}
+
+	var admin models.Admin
+	if err := adminCollection.FindOne(ctx, bson.M{"_id": adminId}).Decode(&admin); err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+
+	var user models.User
+	if err := userCollection.FindOne(ctx, bson.M{"_id": userId}).Decode(&user); err != nil {
+		c.JSON(404, gin.H{"msg": "No such user found"})
+		return
+	}
+	if user.Suspended {
+		c.JSON(400, gin.H{"msg": "Can't impersonate a suspended user"})
+		return
+	}
+
+	// acting as someone must not give the admin permissions their own role lacks
+	perms, err := middleware.RolePermissions(user.Role)
+	if err != nil {
+		c.JSON(500, gin.H{"msg": "Could not load permissions, try again"})
+		return
+	}
+	if !canGrant(c, perms) {
+		c.JSON(403, gin.H{"msg": "You can't impersonate a user with permissions you don't have"})
+		return
+	}
+
+	expiresAt := time.Now().Add(utils.ImpersonationTTL)
+
+	var session models.Session
+	session.ID = primitive.NewObjectID()
+	session.AccountId = user.ID
+	session.AccountType = "user"
+	session.RotatedTokens = []string{}
+	session.RefreshExpiry = expiresAt
+	session.UserAgent = c.Request.UserAgent()
+	session.IP = c.ClientIP()
+	session.Impersonation = &models.Impersonation{
+		AdminId:    admin.ID,
+		AdminEmail: admin.Email,
+		Reason:     strings.TrimSpace(input.Reason),
+		ExpiresAt:  expiresAt,
+	}
+	session.CreatedAt = time.Now()
+	session.LastUsedAt = time.Now()
+
+	_, err = sessionCollection.InsertOne(ctx, session)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+
+	token, _, err := utils.GenerateImpersonationToken(user.ID.Hex(), user.Role, user.Email, session.ID.Hex(), admin.ID.Hex(), admin.Email)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "token generation failed"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"msg":       "Impersonating " + user.Email + ", every request is audited⚠️",
+		"token":     token,
+		"expiresAt": expiresAt,
+		"session":   session,
+	})
+}
+
+// list impersonation sessions, newest first. ?active=true leaves out ended ones, ?adminId filters by admin
+func GetImpersonations(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"impersonation": bson.M{"$exists": true}}
+	if c.Query("active") == "true" {
+		filter["revoked"] = false
+		filter["impersonation.expiresAt"] = bson.M{"$gt": time.Now()}
+	}
+	if v := c.Query("adminId"); v != "" {
+		adminId, err := primitive.ObjectIDFromHex(v)
+		if err != nil {
+			c.JSON(400, gin.H{"msg": "Invalid adminId format"})
+			return
+		}
+		filter["impersonation.adminId"] = adminId
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(200)
+	cursor, err := sessionCollection.Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	sessions := []models.Session{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		c.JSON(400, gin.H{"msg": "decoding error"})
+		return
+	}
+
+	c.JSON(200, gin.H{"msg": "Impersonation Sessions", "sessions": sessions})
+}
+
+// end an impersonation session, its token stops working on the next request
+func StopImpersonation(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	paramId := c.Param("id")
+	mongoId, err := primitive.ObjectIDFromHex(paramId)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "Invalid id format"})
+		return
+	}
+
+	count, err := utils.RevokeSessions(ctx, sessionCollection, bson.M{"_id": mongoId, "impersonation": bson.M{"$exists": true}})
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+	if count == 0 {
+		c.JSON(404, gin.H{"msg": "No active impersonation session found"})
+		return
+	}
+
+	c.JSON(200, gin.H{"msg": "Impersonation Ended✅"})
+}
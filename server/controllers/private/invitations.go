@@ -0,0 +1,153 @@
+package private
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/config"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/middleware"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var invitationCollection *mongo.Collection
+
+func InvitationAccessCollect() {
+	invitationCollection = utils.MongoClient.Database("Event_Booking").Collection("invitations")
+}
+
+// invitation lifetime, default and max
+const (
+	defaultInviteTTL = 72 * time.Hour
+	maxInviteTTL     = 7 * 24 * time.Hour
+)
+
+// invite a new admin by email
+func CreateAdminInvite(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	adminId := c.MustGet("userId").(primitive.ObjectID)
+
+	type InviteInput struct {
+		Email          string `json:"email"`
+		ExpiresInHours int    `json:"expiresInHours"`
+	}
+
+	var input InviteInput
+	if err := c.ShouldBindJSON(&input); err != nil || !strings.Contains(input.Email, "@") {
+		c.JSON(400, gin.H{"msg": "Invalid request"})
+		return
+	}
+
+	ttl := defaultInviteTTL
+	if input.ExpiresInHours > 0 {
+		ttl = time.Duration(input.ExpiresInHours) * time.Hour
+	}
+	if ttl > maxInviteTTL {
+		c.JSON(400, gin.H{"msg": "Invitations can last at most 7 days"})
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(input.Email))
+
+	count, err := adminCollection.CountDocuments(ctx, bson.M{"email": email})
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+	if count > 0 {
+		c.JSON(400, gin.H{"msg": "An admin with this email already exists"})
+		return
+	}
+
+	d := make([]byte, 32)
+	_, _ = rand.Read(d)
+	token := hex.EncodeToString(d)
+
+	var invite models.Invitation
+	invite.ID = primitive.NewObjectID()
+	middleware.SetAuditTarget(c, "invitation", invite.ID.Hex())
+	invite.Email = email
+	invite.TokenHash = utils.HashToken(token)
+	invite.InvitedBy = adminId
+	invite.ExpiresAt = time.Now().Add(ttl)
+	invite.CreatedAt = time.Now()
+
+	_, err = invitationCollection.InsertOne(ctx, invite)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+
+	go func() {
+		emailData := utils.EmailData{
+			From:    "Team Ivents Plannerz🎉",
+			To:      email,
+			Subject: "You are invited as an admin",
+			Html: fmt.Sprintf(`<h2>You are invited to join as an admin</h2><p><a href="%s/admin/signup?invite=%s">Create your admin account</a></p><p>This invitation expires on %s.</p>`,
+				config.AppConfig.FrontendURL, token, invite.ExpiresAt.Format(time.RFC1123)),
+		}
+		_ = utils.SendEmail(emailData)
+	}()
+
+	c.JSON(200, gin.H{"msg": "Invitation sent✉️", "invitation": invite})
+}
+
+// list invitations, newest first
+func GetAdminInvites(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := invitationCollection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var invites []models.Invitation
+	if err := cursor.All(ctx, &invites); err != nil {
+		c.JSON(400, gin.H{"msg": "db decode error"})
+		return
+	}
+
+	c.JSON(200, gin.H{"msg": "All Invitations", "invitations": invites})
+}
+
+// revoke an unused invitation
+func RevokeAdminInvite(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	paramId := c.Param("id")
+	mongoId, err := primitive.ObjectIDFromHex(paramId)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "Invalid id format"})
+		return
+	}
+
+	res, err := invitationCollection.UpdateOne(ctx, bson.M{"_id": mongoId, "used": false}, bson.M{
+		"$set": bson.M{"revoked": true},
+	})
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(404, gin.H{"msg": "No unused invitation found"})
+		return
+	}
+
+	c.JSON(200, gin.H{"msg": "Invitation Revoked✅"})
+}
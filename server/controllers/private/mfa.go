@@ -0,0 +1,231 @@
+package private
+
+import (
+	"context"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/config"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var settingsCollection *mongo.Collection
+
+func SettingsAccessCollect() {
+	settingsCollection = utils.MongoClient.Database("Event_Booking").Collection("settings")
+}
+
+// recovery codes handed out on enrollment
+const mfaRecoveryCodes = 10
+
+// mfaAccount is what the 2FA apis need from either a user or an admin
+type mfaAccount struct {
+	id         primitive.ObjectID
+	collection *mongo.Collection
+	email      string
+	mfa        models.MFA
+}
+
+func loadMFAAccount(ctx context.Context, c *gin.Context) (*mfaAccount, error) {
+	userId := c.MustGet("userId").(primitive.ObjectID)
+
+	if accountType(c) == "admin" {
+		var admin models.Admin
+		if err := adminCollection.FindOne(ctx, bson.M{"_id": userId}).Decode(&admin); err != nil {
+			return nil, err
+		}
+		return &mfaAccount{id: admin.ID, collection: adminCollection, email: admin.Email, mfa: admin.MFA}, nil
+	}
+
+	var user models.User
+	if err := userCollection.FindOne(ctx, bson.M{"_id": userId}).Decode(&user); err != nil {
+		return nil, err
+	}
+	return &mfaAccount{id: user.ID, collection: userCollection, email: user.Email, mfa: user.MFA}, nil
+}
+
+// start 2FA enrollment, returns the secret and the otpauth uri for the app
+func EnrollMFA(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	account, err := loadMFAAccount(ctx, c)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+
+	if account.mfa.Enabled {
+		c.JSON(400, gin.H{"msg": "Two factor auth is already enabled"})
+		return
+	}
+
+	secret := utils.GenerateTOTPSecret()
+	_, err = account.collection.UpdateByID(ctx, account.id, bson.M{
+		"$set": bson.M{
+			"mfa.pendingSecret": secret,
+			"updated_at":        time.Now(),
+		},
+	})
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"msg":             "Scan this in your authenticator app, then confirm a code🔐",
+		"secret":          secret,
+		"provisioningUri": utils.TOTPProvisioningURI(secret, config.AppConfig.AppName, account.email),
+	})
+}
+
+// confirm 2FA enrollment with the first code, returns the recovery codes once
+func ConfirmMFA(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	type ConfirmInput struct {
+		Code string `json:"code"`
+	}
+
+	var input ConfirmInput
+	if err := c.ShouldBindJSON(&input); err != nil || input.Code == "" {
+		c.JSON(400, gin.H{"msg": "Invalid request"})
+		return
+	}
+
+	account, err := loadMFAAccount(ctx, c)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+
+	if account.mfa.Enabled {
+		c.JSON(400, gin.H{"msg": "Two factor auth is already enabled"})
+		return
+	}
+	if account.mfa.PendingSecret == "" {
+		c.JSON(400, gin.H{"msg": "Start the enrollment first"})
+		return
+	}
+
+	step, ok := utils.VerifyTOTP(account.mfa.PendingSecret, input.Code)
+	if !ok {
+		c.JSON(400, gin.H{"msg": "Invalid code❌"})
+		return
+	}
+	_, _ = utils.ClaimTOTPStep(account.id.Hex(), step)
+
+	codes, hashes := utils.GenerateRecoveryCodes(mfaRecoveryCodes)
+	_, err = account.collection.UpdateByID(ctx, account.id, bson.M{
+		"$set": bson.M{
+			"mfa.enabled":       true,
+			"mfa.secret":        account.mfa.PendingSecret,
+			"mfa.pendingSecret": "",
+			"mfa.recoveryCodes": hashes,
+			"mfa.enabledAt":     time.Now(),
+			"updated_at":        time.Now(),
+		},
+	})
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"msg":           "Two factor auth enabled✅ Save these recovery codes, they are shown only once",
+		"recoveryCodes": codes,
+	})
+}
+
+// turn 2FA off, needs a current code. admins can't while 2FA is required for them
+func DisableMFA(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	type DisableInput struct {
+		Code string `json:"code"`
+	}
+
+	var input DisableInput
+	if err := c.ShouldBindJSON(&input); err != nil || input.Code == "" {
+		c.JSON(400, gin.H{"msg": "Invalid request"})
+		return
+	}
+
+	account, err := loadMFAAccount(ctx, c)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+
+	if !account.mfa.Enabled {
+		c.JSON(400, gin.H{"msg": "Two factor auth is not enabled"})
+		return
+	}
+
+	if accountType(c) == "admin" {
+		var settings models.Settings
+		if settingsCollection.FindOne(ctx, bson.M{"_id": "global"}).Decode(&settings) == nil && settings.RequireAdminMFA {
+			c.JSON(403, gin.H{"msg": "Two factor auth is required for admins⚠️"})
+			return
+		}
+	}
+
+	step, ok := utils.VerifyTOTP(account.mfa.Secret, input.Code)
+	if !ok {
+		c.JSON(400, gin.H{"msg": "Invalid code❌"})
+		return
+	}
+	if fresh, err := utils.ClaimTOTPStep(account.id.Hex(), step); err != nil || !fresh {
+		c.JSON(400, gin.H{"msg": "Invalid code❌"})
+		return
+	}
+
+	_, err = account.collection.UpdateByID(ctx, account.id, bson.M{
+		"$set": bson.M{
+			"mfa":        models.MFA{RecoveryCodes: []string{}},
+			"updated_at": time.Now(),
+		},
+	})
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+
+	c.JSON(200, gin.H{"msg": "Two factor auth disabled"})
+}
+
+// admins turn "2FA required for every admin" on or off
+func SetAdminMFARequirement(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	type RequirementInput struct {
+		Required *bool `json:"required"`
+	}
+
+	var input RequirementInput
+	if err := c.ShouldBindJSON(&input); err != nil || input.Required == nil {
+		c.JSON(400, gin.H{"msg": "Invalid request"})
+		return
+	}
+
+	_, err := settingsCollection.UpdateOne(ctx, bson.M{"_id": "global"}, bson.M{
+		"$set": bson.M{
+			"requireAdminMfa": *input.Required,
+			"updated_at":      time.Now(),
+		},
+	}, options.Update().SetUpsert(true))
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+
+	c.JSON(200, gin.H{"msg": "Admin 2FA requirement updated✅", "requireAdminMfa": *input.Required})
+}
@@ -0,0 +1,196 @@
+package private
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	phoneOTPDigits      = 6
+	phoneOTPTTL         = 10 * time.Minute
+	phoneOTPMaxAttempts = 5
+	phoneOTPCooldown    = 60 * time.Second
+)
+
+// phoneAccount is what the phone flow needs from either a user or an admin
+type phoneAccount struct {
+	id            primitive.ObjectID
+	collection    *mongo.Collection
+	tokenField    string
+	verifiedField string
+	phone         string
+	verified      bool
+	otpHash       string
+	otpExpiry     time.Time
+}
+
+func loadPhoneAccount(ctx context.Context, c *gin.Context) (*phoneAccount, error) {
+	userId := c.MustGet("userId").(primitive.ObjectID)
+
+	if accountType(c) == "admin" {
+		var admin models.Admin
+		if err := adminCollection.FindOne(ctx, bson.M{"_id": userId}).Decode(&admin); err != nil {
+			return nil, err
+		}
+		return &phoneAccount{
+			id:            admin.ID,
+			collection:    adminCollection,
+			tokenField:    "adminVerifyToken",
+			verifiedField: "adminVerified",
+			phone:         admin.Phone,
+			verified:      admin.AdminVerified.Phone,
+			otpHash:       admin.AdminVerifyToken.Phone,
+			otpExpiry:     admin.AdminVerifyToken.PhoneExpiry,
+		}, nil
+	}
+
+	var user models.User
+	if err := userCollection.FindOne(ctx, bson.M{"_id": userId}).Decode(&user); err != nil {
+		return nil, err
+	}
+	return &phoneAccount{
+		id:            user.ID,
+		collection:    userCollection,
+		tokenField:    "userverifytoken",
+		verifiedField: "userverified",
+		phone:         user.Phone,
+		verified:      user.Userverified.Phone,
+		otpHash:       user.Userverifytoken.Phone,
+		otpExpiry:     user.Userverifytoken.PhoneExpiry,
+	}, nil
+}
+
+// send phone otp api
+func SendPhoneOTP(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	account, err := loadPhoneAccount(ctx, c)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+
+	if account.verified {
+		c.JSON(200, gin.H{"msg": "Phone Verified already✅"})
+		return
+	}
+
+	// one sms per minute per account
+	ok, err := utils.RedisClient.SetNX(ctx, "otp:phone:cooldown:"+account.id.Hex(), "1", phoneOTPCooldown).Result()
+	if err != nil {
+		c.JSON(500, gin.H{"msg": "redis error"})
+		return
+	}
+	if !ok {
+		c.JSON(429, gin.H{"msg": "Please wait a minute before asking for a new code⚠️"})
+		return
+	}
+
+	otp := utils.GenerateOTP(phoneOTPDigits)
+
+	_, err = account.collection.UpdateByID(ctx, account.id, bson.M{
+		"$set": bson.M{
+			account.tokenField + ".phoneVerifyToken": utils.HashToken(otp),
+			account.tokenField + ".phoneOtpExpiry":   time.Now().Add(phoneOTPTTL),
+			account.tokenField + ".phoneOtpAttempts": 0,
+			"updated_at":                             time.Now(),
+		},
+	})
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+
+	smsData := utils.SMSData{
+		To:   account.phone,
+		Body: fmt.Sprintf("Your Ivents Plannerz verification code is %s. It expires in %d minutes.", otp, int(phoneOTPTTL.Minutes())),
+	}
+	if err := utils.SendSMS(smsData); err != nil {
+		c.JSON(500, gin.H{"msg": "Couldn't send sms, try again"})
+		return
+	}
+
+	c.JSON(200, gin.H{"msg": "Verification code sent to your phone📱"})
+}
+
+// verify phone otp api
+func VerifyPhoneOTP(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	type VerifyInput struct {
+		Code string `json:"code" form:"code"`
+	}
+
+	var input VerifyInput
+	if err := c.ShouldBindJSON(&input); err != nil || len(input.Code) != phoneOTPDigits {
+		c.JSON(400, gin.H{"msg": "Invalid request"})
+		return
+	}
+
+	account, err := loadPhoneAccount(ctx, c)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+
+	if account.verified {
+		c.JSON(200, gin.H{"msg": "Phone Verified already✅"})
+		return
+	}
+
+	if account.otpHash == "" {
+		c.JSON(400, gin.H{"msg": "No code requested, ask for a new one"})
+		return
+	}
+
+	// count the attempt first, atomically, so parallel guesses can't skip the limit
+	res, err := account.collection.UpdateOne(ctx, bson.M{
+		"_id":                                    account.id,
+		account.tokenField + ".phoneOtpAttempts": bson.M{"$lt": phoneOTPMaxAttempts},
+	}, bson.M{"$inc": bson.M{account.tokenField + ".phoneOtpAttempts": 1}})
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(429, gin.H{"msg": "Too many wrong attempts, ask for a new code⚠️"})
+		return
+	}
+
+	if account.otpExpiry.Before(time.Now()) {
+		c.JSON(400, gin.H{"msg": "Code expired, ask for a new one"})
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(utils.HashToken(input.Code)), []byte(account.otpHash)) != 1 {
+		c.JSON(400, gin.H{"msg": "Invalid code❌"})
+		return
+	}
+
+	_, err = account.collection.UpdateByID(ctx, account.id, bson.M{
+		"$set": bson.M{
+			account.verifiedField + ".phoneVerified": true,
+			account.tokenField + ".phoneVerifyToken": "",
+			account.tokenField + ".phoneOtpExpiry":   time.Time{},
+			account.tokenField + ".phoneOtpAttempts": 0,
+			"updated_at":                             time.Now(),
+		},
+	})
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+
+	c.JSON(200, gin.H{"msg": "Phone Verified✨🙌"})
+}
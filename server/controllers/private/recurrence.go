@@ -0,0 +1,501 @@
+package private
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ?scope= of occurrence edits
+const (
+	scopeThis      = "this"
+	scopeFollowing = "following"
+	scopeAll       = "all"
+)
+
+// recurrenceFromForm reads rrule and exdates (comma separated occurrence ids) from the form for schedule.
+// on edit, current is the stored event: no rrule keeps its rule, rrule=none makes it a single event again.
+// its exceptions follow the series when it moves, nil means a single event, 400 is answered here
+func recurrenceFromForm(c *gin.Context, schedule utils.Schedule, current *models.Event) (*models.Recurrence, bool) {
+	rule := strings.TrimSpace(c.PostForm("rrule"))
+	exdates := strings.TrimSpace(c.PostForm("exdates"))
+
+	var old *models.Recurrence
+	if current != nil {
+		old = current.Recurrence
+	}
+	if rule == "" && old != nil {
+		rule = old.RRule
+	}
+	if rule == "" || strings.EqualFold(rule, "none") {
+		if exdates != "" {
+			c.JSON(400, gin.H{"msg": "exdates only go with an rrule"})
+			return nil, false
+		}
+		return nil, true
+	}
+
+	series, err := utils.ParseSeries(rule, schedule)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": err.Error()})
+		return nil, false
+	}
+	rec := &models.Recurrence{RRule: series.Rule}
+
+	if old != nil {
+		rec.SplitFrom = old.SplitFrom
+		if oldSeries, err := utils.SeriesOf(current); err == nil {
+			// same rule: occurrences keep their number when the series moves. a new rule keeps the dates it still has
+			if oldSeries.Rule == series.Rule {
+				moveExceptions(old, rec, byIndex(oldSeries, 0, series))
+			} else {
+				moveExceptions(old, rec, byTime(oldSeries, 0, series))
+			}
+		}
+	}
+
+	if exdates != "" {
+		rec.ExDates = nil
+		if !strings.EqualFold(exdates, "none") {
+			for _, id := range strings.Split(exdates, ",") {
+				t, err := utils.ParseOccurrenceId(strings.TrimSpace(id))
+				if err != nil {
+					c.JSON(400, gin.H{"msg": err.Error()})
+					return nil, false
+				}
+				if series.Index(t) < 0 {
+					c.JSON(400, gin.H{"msg": fmt.Sprintf("%s is not an occurrence of the series", t.Format(time.RFC3339))})
+					return nil, false
+				}
+				if !slices.ContainsFunc(rec.ExDates, t.Equal) {
+					rec.ExDates = append(rec.ExDates, t)
+				}
+			}
+		}
+	}
+
+	if !finishRecurrence(c, series, rec) {
+		return nil, false
+	}
+	return rec, true
+}
+
+// finishRecurrence drops overrides of cancelled occurrences and works out the series end.
+// a series with every occurrence cancelled is refused, 409 is answered here
+func finishRecurrence(c *gin.Context, series *utils.Series, rec *models.Recurrence) bool {
+	rec.Overrides = slices.DeleteFunc(rec.Overrides, func(ov models.OccurrenceOverride) bool {
+		return slices.ContainsFunc(rec.ExDates, ov.OriginalStart.Equal)
+	})
+	if len(series.Expand(rec, models.Occurrence{}, time.Time{}, time.Time{})) == 0 {
+		c.JSON(409, gin.H{"msg": "Every occurrence would be cancelled, cancel the event instead"})
+		return false
+	}
+	rec.EndAt = series.EndAt(rec)
+	return true
+}
+
+// moveExceptions copies the exdates and overrides of from that mapStart finds a new occurrence for into into
+func moveExceptions(from *models.Recurrence, into *models.Recurrence, mapStart func(time.Time) (time.Time, bool)) {
+	for _, t := range from.ExDates {
+		if moved, ok := mapStart(t); ok {
+			into.ExDates = append(into.ExDates, moved)
+		}
+	}
+	for _, ov := range from.Overrides {
+		if moved, ok := mapStart(ov.OriginalStart); ok {
+			ov.OriginalStart = moved
+			into.Overrides = append(into.Overrides, ov)
+		}
+	}
+}
+
+// byIndex maps occurrence i of old, from first on, to occurrence i-first of next
+func byIndex(old *utils.Series, first int, next *utils.Series) func(time.Time) (time.Time, bool) {
+	return func(t time.Time) (time.Time, bool) {
+		i := old.Index(t)
+		if i < first || i-first >= next.Len() {
+			return time.Time{}, false
+		}
+		return next.Start(i - first), true
+	}
+}
+
+// byTime keeps occurrences of old, from first on, that next has at the same time
+func byTime(old *utils.Series, first int, next *utils.Series) func(time.Time) (time.Time, bool) {
+	return func(t time.Time) (time.Time, bool) {
+		if old.Index(t) < first || next.Index(t) < 0 {
+			return time.Time{}, false
+		}
+		return t, true
+	}
+}
+
+// recurrenceIndexes backs range queries and the scheduler on series, they match on the last occurrence
+func recurrenceIndexes(coll *mongo.Collection) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "recurrence.seriesEndAt", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "recurrence.seriesEndAt", Value: 1}}, Options: options.Index().SetSparse(true)},
+	})
+	if err != nil {
+		fmt.Println("⚠️ couldn't create recurrence indexes on", coll.Name(), err)
+	}
+}
+
+// loadOccurrence reads ?scope= and finds my upcoming series :id and its occurrence :occurrenceId.
+// answers errors itself
+func loadOccurrence(ctx context.Context, c *gin.Context) (*models.Event, *utils.Series, int, string, bool) {
+	scope := c.DefaultQuery("scope", scopeThis)
+	if scope != scopeThis && scope != scopeFollowing && scope != scopeAll {
+		c.JSON(400, gin.H{"msg": "scope must be this, following or all"})
+		return nil, nil, 0, "", false
+	}
+
+	userId := c.MustGet("userId").(primitive.ObjectID)
+	eventId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "Invalid param Id"})
+		return nil, nil, 0, "", false
+	}
+	start, err := utils.ParseOccurrenceId(c.Param("occurrenceId"))
+	if err != nil {
+		c.JSON(400, gin.H{"msg": err.Error()})
+		return nil, nil, 0, "", false
+	}
+
+	var event models.Event
+	if err := eventsCollection.FindOne(ctx, bson.M{"_id": eventId, "userId": userId}).Decode(&event); err != nil {
+		c.JSON(404, gin.H{"msg": "No event found ❌"})
+		return nil, nil, 0, "", false
+	}
+	if event.Recurrence == nil {
+		c.JSON(400, gin.H{"msg": "This event doesn't repeat, edit it with /updateevent/:id"})
+		return nil, nil, 0, "", false
+	}
+	if event.Status != models.StatusUpcoming {
+		c.JSON(409, gin.H{"msg": "Only an Upcoming series can be changed"})
+		return nil, nil, 0, "", false
+	}
+	series, err := utils.SeriesOf(&event)
+	if err != nil {
+		c.JSON(500, gin.H{"msg": "The stored rrule is broken: " + err.Error()})
+		return nil, nil, 0, "", false
+	}
+	i := series.Index(start)
+	if i < 0 {
+		c.JSON(404, gin.H{"msg": "No such occurrence ❌"})
+		return nil, nil, 0, "", false
+	}
+
+	// the first occurrence and what follows is the whole series
+	if scope == scopeFollowing && i == 0 {
+		scope = scopeAll
+	}
+	return &event, series, i, scope, true
+}
+
+// saveSeries applies update to the event as it was read, 409 when it changed meanwhile
+func saveSeries(ctx context.Context, c *gin.Context, event *models.Event, update bson.M) bool {
+	if update["$set"] == nil {
+		update["$set"] = bson.M{}
+	}
+	update["$set"].(bson.M)["updated_at"] = time.Now()
+
+	res, err := eventsCollection.UpdateOne(ctx, bson.M{"_id": event.ID, "userId": event.UserId, "updated_at": event.UpdatedAt}, update)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return false
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(409, gin.H{"msg": "Event changed meanwhile, reload and try again"})
+		return false
+	}
+	return true
+}
+
+// copyRecurrence is rec with its own slices, so edits don't touch the event read from the db
+func copyRecurrence(rec *models.Recurrence) *models.Recurrence {
+	out := *rec
+	out.ExDates = slices.Clone(rec.ExDates)
+	out.Overrides = slices.Clone(rec.Overrides)
+	return &out
+}
+
+// edit one occurrence of my series (scope=this), it and the ones after it (following) or all of them.
+// eventname, eventdesc, location, lat/lng, startAt and endAt are optional, times are those of this occurrence
+// and move the others by as much. following splits the series in two, RSVPs stay with the first part
+func EditOccurrence(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	event, series, i, scope, ok := loadOccurrence(ctx, c)
+	if !ok {
+		return
+	}
+	userId := event.UserId
+	start := series.Start(i)
+
+	eventName := c.PostForm("eventname")
+	eventDes := c.PostForm("eventdesc")
+	location := c.PostForm("location")
+
+	switch scope {
+	case scopeThis:
+		if slices.ContainsFunc(event.Recurrence.ExDates, start.Equal) {
+			c.JSON(409, gin.H{"msg": "This occurrence is cancelled"})
+			return
+		}
+		rec := copyRecurrence(event.Recurrence)
+		override := utils.FindOverride(rec, start)
+		if override == nil {
+			rec.Overrides = append(rec.Overrides, models.OccurrenceOverride{
+				OriginalStart: start,
+				StartAt:       start,
+				EndAt:         start.Add(series.Duration()),
+			})
+			override = &rec.Overrides[len(rec.Overrides)-1]
+		}
+
+		schedule, ok := scheduleFromForm(c, &utils.Schedule{StartAt: override.StartAt, EndAt: override.EndAt, Timezone: event.Timezone})
+		if !ok {
+			return
+		}
+		override.StartAt = schedule.StartAt
+		override.EndAt = schedule.EndAt
+		if eventName != "" {
+			override.EventName = eventName
+		}
+		if eventDes != "" {
+			override.EventDescription = eventDes
+		}
+		curLocation := event.Location
+		if override.Location != "" {
+			curLocation = override.Location
+		}
+		if location != "" && (location != curLocation || c.PostForm("lat") != "") {
+			coordinates, ok := coordinatesFromForm(c, location, location != curLocation)
+			if !ok {
+				return
+			}
+			override.Location = location
+			override.Coordinates = coordinates
+		}
+
+		if !finishRecurrence(c, series, rec) {
+			return
+		}
+		if !saveSeries(ctx, c, event, bson.M{"$set": bson.M{"recurrence": rec}}) {
+			return
+		}
+		c.JSON(200, gin.H{"msg": "Occurrence Updated✅", "occurrenceId": start.Format(time.RFC3339), "recurrence": rec})
+
+	case scopeAll:
+		// the series moves by as much as this occurrence does
+		schedule, ok := scheduleFromForm(c, &utils.Schedule{StartAt: start, EndAt: start.Add(series.Duration()), Timezone: event.Timezone})
+		if !ok {
+			return
+		}
+		moved, err := utils.ParseSchedule(
+			event.StartAt.Add(schedule.StartAt.Sub(start)).Format(time.RFC3339),
+			event.EndAt.Add(schedule.EndAt.Sub(start.Add(series.Duration()))).Format(time.RFC3339),
+			schedule.Timezone,
+		)
+		if err != nil {
+			c.JSON(400, gin.H{"msg": err.Error()})
+			return
+		}
+		rec, ok := recurrenceFromForm(c, moved, event)
+		if !ok {
+			return
+		}
+		if rec == nil {
+			c.JSON(400, gin.H{"msg": "use /updateevent/:id to stop an event repeating"})
+			return
+		}
+
+		set := bson.M{"startAt": moved.StartAt, "endAt": moved.EndAt, "timezone": moved.Timezone, "recurrence": rec}
+		if eventName != "" {
+			set["eventname"] = eventName
+		}
+		if eventDes != "" {
+			set["eventdesc"] = eventDes
+		}
+		update := bson.M{"$set": set}
+		if location != "" {
+			coordinates, ok := coordinatesFromForm(c, location, location != event.Location)
+			if !ok {
+				return
+			}
+			set["location"] = location
+			if coordinates != nil {
+				set["coordinates"] = coordinates
+			} else if location != event.Location {
+				update["$unset"] = bson.M{"coordinates": ""}
+			}
+		}
+
+		if !saveSeries(ctx, c, event, update) {
+			return
+		}
+		c.JSON(200, gin.H{"msg": "Series Updated✅", "recurrence": rec})
+
+	case scopeFollowing:
+		schedule, ok := scheduleFromForm(c, &utils.Schedule{StartAt: start, EndAt: start.Add(series.Duration()), Timezone: event.Timezone})
+		if !ok {
+			return
+		}
+		head, err := series.Before(i)
+		if err != nil {
+			c.JSON(400, gin.H{"msg": err.Error()})
+			return
+		}
+
+		// the rest keeps the rule unless a new one is given
+		var tail *utils.Series
+		rule := strings.TrimSpace(c.PostForm("rrule"))
+		if rule != "" {
+			tail, err = utils.ParseSeries(rule, schedule)
+		} else {
+			tail, err = series.From(i, schedule)
+		}
+		if err != nil {
+			c.JSON(400, gin.H{"msg": err.Error()})
+			return
+		}
+
+		headRec := &models.Recurrence{RRule: head.Rule, SplitFrom: event.Recurrence.SplitFrom}
+		moveExceptions(event.Recurrence, headRec, byTime(series, 0, head))
+		if !finishRecurrence(c, head, headRec) {
+			return
+		}
+		tailRec := &models.Recurrence{RRule: tail.Rule, SplitFrom: event.ID}
+		if rule != "" {
+			moveExceptions(event.Recurrence, tailRec, byTime(series, i, tail))
+		} else {
+			moveExceptions(event.Recurrence, tailRec, byIndex(series, i, tail))
+		}
+		if !finishRecurrence(c, tail, tailRec) {
+			return
+		}
+
+		newEvent := *event
+		newEvent.ID = primitive.NewObjectID()
+		newEvent.StartAt = schedule.StartAt
+		newEvent.EndAt = schedule.EndAt
+		newEvent.Timezone = schedule.Timezone
+		newEvent.Recurrence = tailRec
+		newEvent.StatusHistory = []models.StatusChange{{To: models.StatusUpcoming, At: time.Now(), By: userId, Source: "user"}}
+		newEvent.ConfirmedCount = 0
+		newEvent.TicketTypes = nil
+		for _, t := range event.TicketTypes {
+			t.Remaining = t.Quota
+			newEvent.TicketTypes = append(newEvent.TicketTypes, t)
+		}
+		newEvent.CreatedAt = time.Now()
+		newEvent.UpdatedAt = time.Now()
+		if eventName != "" {
+			newEvent.EventName = eventName
+		}
+		if eventDes != "" {
+			newEvent.EventDescription = eventDes
+		}
+		if location != "" {
+			coordinates, ok := coordinatesFromForm(c, location, location != event.Location)
+			if !ok {
+				return
+			}
+			if coordinates != nil || location != event.Location {
+				newEvent.Coordinates = coordinates
+			}
+			newEvent.Location = location
+		}
+
+		// the new series goes in first and is taken back out if the old one changed meanwhile
+		if _, err := eventsCollection.InsertOne(ctx, newEvent); err != nil {
+			c.JSON(400, gin.H{"msg": "db error"})
+			return
+		}
+		if !saveSeries(ctx, c, event, bson.M{"$set": bson.M{"recurrence": headRec}}) {
+			_, _ = eventsCollection.DeleteOne(ctx, bson.M{"_id": newEvent.ID})
+			return
+		}
+		c.JSON(200, gin.H{"msg": "Series Split✅", "recurrence": headRec, "newEvent": newEvent})
+	}
+}
+
+// cancel one occurrence of my series (scope=this), it and the ones after it (following) or the whole series (all)
+func CancelOccurrence(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	event, series, i, scope, ok := loadOccurrence(ctx, c)
+	if !ok {
+		return
+	}
+	start := series.Start(i)
+
+	switch scope {
+	case scopeThis:
+		if slices.ContainsFunc(event.Recurrence.ExDates, start.Equal) {
+			c.JSON(409, gin.H{"msg": "This occurrence is already cancelled"})
+			return
+		}
+		rec := copyRecurrence(event.Recurrence)
+		rec.ExDates = append(rec.ExDates, start)
+		if !finishRecurrence(c, series, rec) {
+			return
+		}
+		if !saveSeries(ctx, c, event, bson.M{"$set": bson.M{"recurrence": rec}}) {
+			return
+		}
+		c.JSON(200, gin.H{"msg": "Occurrence Cancelled✅", "occurrenceId": start.Format(time.RFC3339), "recurrence": rec})
+
+	case scopeFollowing:
+		head, err := series.Before(i)
+		if err != nil {
+			c.JSON(400, gin.H{"msg": err.Error()})
+			return
+		}
+		rec := &models.Recurrence{RRule: head.Rule, SplitFrom: event.Recurrence.SplitFrom}
+		moveExceptions(event.Recurrence, rec, byTime(series, 0, head))
+		if !finishRecurrence(c, head, rec) {
+			return
+		}
+		if !saveSeries(ctx, c, event, bson.M{"$set": bson.M{"recurrence": rec}}) {
+			return
+		}
+		c.JSON(200, gin.H{"msg": "Following Occurrences Cancelled✅", "recurrence": rec})
+
+	case scopeAll:
+		if err := utils.CheckStatusTransition(event.Status, models.StatusCancelled); err != nil {
+			c.JSON(409, gin.H{"msg": err.Error(), "code": "ILLEGAL_STATUS_TRANSITION"})
+			return
+		}
+		update := bson.M{
+			"$set": bson.M{"status": models.StatusCancelled},
+			"$push": bson.M{"statusHistory": models.StatusChange{
+				From:   event.Status,
+				To:     models.StatusCancelled,
+				At:     time.Now(),
+				By:     event.UserId,
+				Source: "user",
+			}},
+		}
+		if !saveSeries(ctx, c, event, update) {
+			return
+		}
+		c.JSON(200, gin.H{"msg": "Series Cancelled✅"})
+	}
+}
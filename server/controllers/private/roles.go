@@ -0,0 +1,250 @@
+package private
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/middleware"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var roleCollection *mongo.Collection
+
+func RoleAccessCollect() {
+	roleCollection = utils.MongoClient.Database("Event_Booking").Collection("roles")
+}
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,31}$`)
+
+// list roles with their permissions
+func GetRoles(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := roleCollection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var roles []models.Role
+	if err := cursor.All(ctx, &roles); err != nil {
+		c.JSON(400, gin.H{"msg": "db decode error"})
+		return
+	}
+
+	c.JSON(200, gin.H{"msg": "All Roles", "roles": roles, "permissions": models.Permissions})
+}
+
+// create or replace a role's permissions
+func SaveRole(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	name := c.Param("name")
+	middleware.SetAuditTarget(c, "role", name)
+	if !roleNamePattern.MatchString(name) {
+		c.JSON(400, gin.H{"msg": "Role names are 2-32 lowercase letters, digits, - or _"})
+		return
+	}
+	// admin must keep every permission, otherwise nobody can fix roles anymore
+	if name == "admin" {
+		c.JSON(403, gin.H{"msg": "The admin role can't be changed"})
+		return
+	}
+
+	type RoleInput struct {
+		Permissions []string `json:"permissions"`
+	}
+
+	var input RoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"msg": "Invalid request"})
+		return
+	}
+
+	perms := []string{}
+	for _, p := range input.Permissions {
+		if !containsString(models.Permissions, p) {
+			c.JSON(400, gin.H{"msg": "Unknown permission: " + p, "permissions": models.Permissions})
+			return
+		}
+		if !containsString(perms, p) {
+			perms = append(perms, p)
+		}
+	}
+
+	// can't hand out more than you have
+	if !canGrant(c, perms) {
+		c.JSON(403, gin.H{"msg": "You can't grant permissions you don't have"})
+		return
+	}
+
+	_, err := roleCollection.UpdateOne(ctx, bson.M{"_id": name}, bson.M{
+		"$set":         bson.M{"permissions": perms, "updated_at": time.Now()},
+		"$setOnInsert": bson.M{"builtin": false},
+	}, options.Update().SetUpsert(true))
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+
+	middleware.InvalidateRoleCache()
+
+	c.JSON(200, gin.H{"msg": "Role Saved✅", "role": models.Role{Name: name, Permissions: perms}})
+}
+
+// delete a custom role nobody is using
+func DeleteRole(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	name := c.Param("name")
+	middleware.SetAuditTarget(c, "role", name)
+
+	users, err := userCollection.CountDocuments(ctx, bson.M{"role": name})
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+	admins, err := adminCollection.CountDocuments(ctx, bson.M{"role": name})
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+	if users+admins > 0 {
+		c.JSON(400, gin.H{"msg": "Role is still assigned to accounts, reassign them first"})
+		return
+	}
+
+	res, err := roleCollection.DeleteOne(ctx, bson.M{"_id": name, "builtin": false})
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+	if res.DeletedCount == 0 {
+		c.JSON(404, gin.H{"msg": "No custom role found"})
+		return
+	}
+
+	middleware.InvalidateRoleCache()
+
+	c.JSON(200, gin.H{"msg": "Role Deleted✅"})
+}
+
+// give an account a role, its current tokens stop working so the next refresh picks it up
+func AssignRole(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	type AssignInput struct {
+		AccountId   string `json:"accountId"`
+		AccountType string `json:"accountType"`
+		Role        string `json:"role"`
+	}
+
+	var input AssignInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"msg": "Invalid request"})
+		return
+	}
+
+	mongoId, err := primitive.ObjectIDFromHex(input.AccountId)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "Invalid id format"})
+		return
+	}
+
+	middleware.SetAuditTarget(c, input.AccountType, input.AccountId)
+
+	coll := userCollection
+	if input.AccountType == "admin" {
+		coll = adminCollection
+	} else if input.AccountType != "user" {
+		c.JSON(400, gin.H{"msg": "accountType must be user or admin"})
+		return
+	}
+
+	var role models.Role
+	if err := roleCollection.FindOne(ctx, bson.M{"_id": input.Role}).Decode(&role); err != nil {
+		c.JSON(404, gin.H{"msg": "No such role found"})
+		return
+	}
+
+	if !canGrant(c, role.Permissions) {
+		c.JSON(403, gin.H{"msg": "You can't assign a role with permissions you don't have"})
+		return
+	}
+
+	// taking a role away is as strong as giving it, so the caller must hold the current one too
+	var target struct {
+		Role string `bson:"role"`
+	}
+	if err := coll.FindOne(ctx, bson.M{"_id": mongoId}).Decode(&target); err != nil {
+		c.JSON(404, gin.H{"msg": "No such account found"})
+		return
+	}
+
+	var currentRole models.Role
+	err = roleCollection.FindOne(ctx, bson.M{"_id": target.Role}).Decode(&currentRole)
+	if err != nil && err != mongo.ErrNoDocuments {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+	if !canGrant(c, currentRole.Permissions) {
+		c.JSON(403, gin.H{"msg": "You can't change the role of an account with permissions you don't have"})
+		return
+	}
+
+	// the bootstrap super admin always stays an admin
+	res, err := coll.UpdateOne(ctx, bson.M{"_id": mongoId, "superAdmin": bson.M{"$ne": true}}, bson.M{
+		"$set": bson.M{"role": role.Name},
+	})
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(404, gin.H{"msg": "No such account found, or it is the super admin"})
+		return
+	}
+
+	if err := utils.DenyAccount(mongoId.Hex()); err != nil {
+		c.JSON(500, gin.H{"msg": "role assigned but old tokens couldn't be revoked"})
+		return
+	}
+
+	c.JSON(200, gin.H{"msg": "Role Assigned✅", "role": role.Name})
+}
+
+// canGrant reports whether the caller's own role holds every permission in perms
+func canGrant(c *gin.Context, perms []string) bool {
+	mine, err := middleware.RolePermissions(c.GetString("role"))
+	if err != nil {
+		return false
+	}
+	for _, p := range perms {
+		if !containsString(mine, p) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
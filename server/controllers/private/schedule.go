@@ -0,0 +1,78 @@
+package private
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// scheduleFromForm reads startAt, endAt and timezone from the form, answers 400 itself when they're invalid.
+// on edit, current is the stored schedule and fields left out of the form keep their value
+func scheduleFromForm(c *gin.Context, current *utils.Schedule) (utils.Schedule, bool) {
+	start := c.PostForm("startAt")
+	end := c.PostForm("endAt")
+	timezone := c.PostForm("timezone")
+
+	if current != nil && !current.StartAt.IsZero() {
+		if start == "" {
+			start = current.StartAt.Format(time.RFC3339)
+		}
+		if end == "" {
+			end = current.EndAt.Format(time.RFC3339)
+		}
+		if timezone == "" {
+			timezone = current.Timezone
+		}
+	}
+
+	schedule, err := utils.ParseSchedule(start, end, timezone)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": err.Error()})
+		return utils.Schedule{}, false
+	}
+	return schedule, true
+}
+
+// rangeFromQuery reads ?from=&to= and adds them to filter, matching anything that overlaps the range,
+// a series by its first and last occurrence. the returned string goes into cache keys
+func rangeFromQuery(c *gin.Context, filter bson.M) (time.Time, time.Time, string, bool) {
+	from, to, err := utils.ParseRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(400, gin.H{"msg": err.Error()})
+		return from, to, "", false
+	}
+	if !to.IsZero() {
+		filter["startAt"] = bson.M{"$lt": to}
+	}
+	if !from.IsZero() {
+		filter["$or"] = utils.EndsAfter(from)
+	}
+	return from, to, fmt.Sprintf("%d:%d", unixOrZero(from), unixOrZero(to)), true
+}
+
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// scheduleIndexes backs the from/to queries on events and functions, and the status scheduler
+func scheduleIndexes(coll *mongo.Collection) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "startAt", Value: 1}, {Key: "endAt", Value: 1}}},
+		{Keys: bson.D{{Key: "startAt", Value: 1}, {Key: "endAt", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "endAt", Value: 1}}},
+	})
+	if err != nil {
+		fmt.Println("⚠️ couldn't create schedule indexes on", coll.Name(), err)
+	}
+}
@@ -0,0 +1,36 @@
+package private
+
+import (
+	"context"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// search my own events and functions
+func SearchMine(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userId := c.MustGet("userId").(primitive.ObjectID)
+
+	query, ok := utils.ParseSearchQuery(c)
+	if !ok {
+		return
+	}
+
+	// owners see their own documents as they are
+	response, err := utils.Search(ctx, query, "mine", bson.M{"userId": userId}, eventsCollection, functionCollection,
+		func(e models.Event) models.Event { return e },
+		func(f models.Function) models.Function { return f })
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+
+	c.JSON(200, response)
+}
@@ -0,0 +1,158 @@
+package private
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var sessionCollection *mongo.Collection
+
+func SessionAccessCollect() {
+	sessionCollection = utils.MongoClient.Database("Event_Booking").Collection("sessions")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// refresh looks sessions up by token, listing and revoking by account
+	_, err := sessionCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "refreshToken", Value: 1}}},
+		{Keys: bson.D{{Key: "rotatedTokens", Value: 1}}},
+		{Keys: bson.D{{Key: "accountId", Value: 1}, {Key: "accountType", Value: 1}}},
+	})
+	if err != nil {
+		fmt.Println("⚠️ couldn't create session indexes", err)
+	}
+}
+
+// accountType is the collection the account lives in, set by AuthMiddleware
+func accountType(c *gin.Context) string {
+	if c.GetString("accountType") == "admin" {
+		return "admin"
+	}
+	return "user"
+}
+
+// list my active sessions
+func ListSessions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userId := c.MustGet("userId").(primitive.ObjectID)
+	currentId := c.GetString("sessionId")
+
+	opts := options.Find().SetSort(bson.D{{Key: "last_used_at", Value: -1}})
+	cursor, err := sessionCollection.Find(ctx, bson.M{
+		"accountId":     userId,
+		"accountType":   accountType(c),
+		"revoked":       false,
+		"refreshExpiry": bson.M{"$gt": time.Now()},
+	}, opts)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var sessions []models.Session
+	if err := cursor.All(ctx, &sessions); err != nil {
+		c.JSON(400, gin.H{"msg": "decoding error"})
+		return
+	}
+
+	type sessionView struct {
+		models.Session
+		Current bool `json:"current"`
+	}
+	views := make([]sessionView, 0, len(sessions))
+	for _, s := range sessions {
+		views = append(views, sessionView{Session: s, Current: s.ID.Hex() == currentId})
+	}
+
+	c.JSON(200, gin.H{"msg": "Your Active Sessions✨", "sessions": views})
+}
+
+// revoke one of my sessions
+func RevokeSession(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userId := c.MustGet("userId").(primitive.ObjectID)
+
+	paramId := c.Param("id")
+	mongoId, err := primitive.ObjectIDFromHex(paramId)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "Invalid param Id"})
+		return
+	}
+
+	count, err := utils.RevokeSessions(ctx, sessionCollection, bson.M{"_id": mongoId, "accountId": userId, "accountType": accountType(c)})
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+	if count == 0 {
+		c.JSON(404, gin.H{"msg": "No active session found❌"})
+		return
+	}
+
+	c.JSON(200, gin.H{"msg": "Session Revoked✅"})
+}
+
+// revoke every session except the one making this request
+func RevokeOtherSessions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userId := c.MustGet("userId").(primitive.ObjectID)
+
+	// without a sid we can't tell which session to keep
+	currentId, err := primitive.ObjectIDFromHex(c.GetString("sessionId"))
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "No session found in token"})
+		return
+	}
+
+	count, err := utils.RevokeSessions(ctx, sessionCollection, bson.M{"accountId": userId, "accountType": accountType(c), "_id": bson.M{"$ne": currentId}})
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+
+	c.JSON(200, gin.H{"msg": "All Other Sessions Revoked✅", "revoked": count})
+}
+
+// logoutCurrentSession revokes only the session the access token was issued for
+func logoutCurrentSession(c *gin.Context) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userId := c.MustGet("userId").(primitive.ObjectID)
+
+	sessionId, err := primitive.ObjectIDFromHex(c.GetString("sessionId"))
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "No session found in token"})
+		return false
+	}
+
+	_, err = utils.RevokeSessions(ctx, sessionCollection, bson.M{"_id": sessionId, "accountId": userId, "accountType": accountType(c)})
+	if err != nil {
+		c.JSON(500, gin.H{"msg": "Could not logout, try again"})
+		return false
+	}
+
+	// the access token itself stays valid till exp unless we denylist it
+	if err := utils.DenyToken(c.GetString("jti"), c.GetTime("tokenExp")); err != nil {
+		c.JSON(500, gin.H{"msg": "Could not logout, try again"})
+		return false
+	}
+	return true
+}
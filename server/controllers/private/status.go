@@ -0,0 +1,105 @@
+package private
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/config"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// initialStatus checks the status of a new event/function, everything starts Upcoming
+func initialStatus(c *gin.Context, userId primitive.ObjectID) ([]models.StatusChange, bool) {
+	status := c.PostForm("status")
+	if status != "" && status != models.StatusUpcoming {
+		c.JSON(400, gin.H{"msg": "new events and functions start as Upcoming"})
+		return nil, false
+	}
+	return []models.StatusChange{{
+		To:     models.StatusUpcoming,
+		At:     time.Now(),
+		By:     userId,
+		Source: "user",
+	}}, true
+}
+
+// statusFromForm puts a status change asked for in the form into filter/update.
+// the filter pins the current status so a concurrent change (say the scheduler) makes the update miss
+func statusFromForm(c *gin.Context, userId primitive.ObjectID, current string, filter bson.M, update bson.M) bool {
+	status := c.PostForm("status")
+	if status == "" || status == current {
+		return true
+	}
+
+	if err := utils.CheckStatusTransition(current, status); err != nil {
+		c.JSON(409, gin.H{"msg": err.Error(), "code": "ILLEGAL_STATUS_TRANSITION"})
+		return false
+	}
+
+	filter["status"] = current
+	update["$set"].(bson.M)["status"] = status
+	update["$push"] = bson.M{"statusHistory": models.StatusChange{
+		From:   current,
+		To:     status,
+		At:     time.Now(),
+		By:     userId,
+		Source: "user",
+	}}
+	return true
+}
+
+// StartStatusScheduler completes Upcoming events and functions once their end time has passed,
+// a series once its last occurrence has.
+// the update is guarded by status so running it on several instances at once is harmless
+func StartStatusScheduler() {
+	if !config.AppConfig.Scheduler.Enabled {
+		return
+	}
+	interval := config.AppConfig.Scheduler.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			completeFinished(eventsCollection, "events")
+			completeFinished(functionCollection, "functions")
+			<-ticker.C
+		}
+	}()
+}
+
+func completeFinished(coll *mongo.Collection, what string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	res, err := coll.UpdateMany(ctx, bson.M{
+		"status": models.StatusUpcoming,
+		"endAt":  bson.M{"$lte": now},
+		"$nor":   utils.EndsAfter(now),
+	}, bson.M{
+		"$set": bson.M{"status": models.StatusCompleted, "updated_at": now},
+		"$push": bson.M{"statusHistory": models.StatusChange{
+			From:   models.StatusUpcoming,
+			To:     models.StatusCompleted,
+			At:     now,
+			Source: "scheduler",
+		}},
+	})
+	if err != nil {
+		fmt.Println("⚠️ scheduler couldn't complete", what, err)
+		return
+	}
+	if res.ModifiedCount > 0 {
+		fmt.Printf("✅ scheduler completed %d %s\n", res.ModifiedCount, what)
+	}
+}
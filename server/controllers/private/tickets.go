@@ -0,0 +1,305 @@
+package private
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// size of the ticket qr png, in pixels
+const ticketQRSize = 512
+
+// set the ticket types of my event, quotas share the event's attendence
+func SetTicketTypes(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userId := c.MustGet("userId").(primitive.ObjectID)
+	eventId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "Invalid param Id"})
+		return
+	}
+
+	type TicketTypeInput struct {
+		Name       string    `json:"name"`
+		Quota      int       `json:"quota"`
+		SalesEndAt time.Time `json:"salesEndAt"`
+	}
+	type TicketTypesInput struct {
+		TicketTypes []TicketTypeInput `json:"ticketTypes"`
+	}
+
+	var input TicketTypesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"msg": "invalid request"})
+		return
+	}
+
+	var event models.Event
+	if err := eventsCollection.FindOne(ctx, bson.M{"_id": eventId, "userId": userId}).Decode(&event); err != nil {
+		c.JSON(404, gin.H{"msg": "No event found ❌"})
+		return
+	}
+
+	sold := map[string]int{}
+	for _, t := range event.TicketTypes {
+		sold[t.Name] = t.Quota - t.Remaining
+	}
+
+	total := 0
+	ticketTypes := []models.TicketType{}
+	for _, t := range input.TicketTypes {
+		if !slices.Contains(models.TicketTypeNames, t.Name) {
+			c.JSON(400, gin.H{"msg": fmt.Sprintf("%q is not a ticket type, use General, VIP or Early bird", t.Name)})
+			return
+		}
+		if slices.ContainsFunc(ticketTypes, func(existing models.TicketType) bool { return existing.Name == t.Name }) {
+			c.JSON(400, gin.H{"msg": t.Name + " is listed twice"})
+			return
+		}
+		if t.Quota < 1 || t.Quota < sold[t.Name] {
+			c.JSON(400, gin.H{"msg": fmt.Sprintf("%s quota must be at least %d", t.Name, max(1, sold[t.Name]))})
+			return
+		}
+		total += t.Quota
+		ticketTypes = append(ticketTypes, models.TicketType{
+			Name:       t.Name,
+			Quota:      t.Quota,
+			Remaining:  t.Quota - sold[t.Name],
+			SalesEndAt: t.SalesEndAt,
+		})
+		delete(sold, t.Name)
+	}
+
+	if total > event.EventAttendence {
+		c.JSON(400, gin.H{"msg": fmt.Sprintf("quotas add up to %d, the event only has %d places", total, event.EventAttendence)})
+		return
+	}
+	for name, n := range sold {
+		if n > 0 {
+			c.JSON(409, gin.H{"msg": fmt.Sprintf("%d %s tickets are already issued, that type can't be removed", n, name)})
+			return
+		}
+	}
+
+	// only write over what we read, a ticket sold in between makes this miss
+	var current interface{} = event.TicketTypes
+	if len(event.TicketTypes) == 0 {
+		current = nil
+	}
+	update := bson.M{"$set": bson.M{"ticketTypes": ticketTypes, "updated_at": time.Now()}}
+	if len(ticketTypes) == 0 {
+		update = bson.M{"$unset": bson.M{"ticketTypes": ""}, "$set": bson.M{"updated_at": time.Now()}}
+	}
+
+	res, err := eventsCollection.UpdateOne(ctx, bson.M{"_id": eventId, "userId": userId, "ticketTypes": current}, update)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(409, gin.H{"msg": "Tickets were sold meanwhile, reload and try again"})
+		return
+	}
+
+	promoteAllWaitlists(ctx, eventId)
+
+	c.JSON(200, gin.H{"msg": "Ticket types saved✅", "ticketTypes": ticketTypes})
+}
+
+// my ticket for an event, the code is what the qr carries
+func GetMyTicket(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	booking, code, ok := myTicket(ctx, c)
+	if !ok {
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"msg":        "Your ticket🎟️",
+		"ticketId":   booking.TicketId,
+		"ticketType": booking.TicketType,
+		"code":       code,
+	})
+}
+
+// my ticket as a qr code png
+func GetMyTicketQR(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, code, ok := myTicket(ctx, c)
+	if !ok {
+		return
+	}
+
+	png, err := utils.TicketQR(code, ticketQRSize)
+	if err != nil {
+		c.JSON(500, gin.H{"msg": "Couldn't render the qr code"})
+		return
+	}
+	c.Header("Cache-Control", "private, no-store")
+	c.Data(200, "image/png", png)
+}
+
+// door check-in: verify the signed code, mark the ticket used, a second scan is rejected.
+// scanners that worked offline send scannedAt when they sync. door staff get an api token of the
+// event owner with only the tickets:checkin scope, so they can scan but not edit the event
+func CheckInTicket(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userId := c.MustGet("userId").(primitive.ObjectID)
+	eventId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "Invalid param Id"})
+		return
+	}
+
+	type CheckInInput struct {
+		Code      string    `json:"code"`
+		ScannedAt time.Time `json:"scannedAt"`
+	}
+
+	var input CheckInInput
+	if err := c.ShouldBindJSON(&input); err != nil || input.Code == "" {
+		c.JSON(400, gin.H{"msg": "invalid request"})
+		return
+	}
+	checkedInAt := time.Now()
+	if !input.ScannedAt.IsZero() {
+		if input.ScannedAt.After(checkedInAt.Add(time.Minute)) {
+			c.JSON(400, gin.H{"msg": "scannedAt is in the future"})
+			return
+		}
+		checkedInAt = input.ScannedAt
+	}
+
+	count, err := eventsCollection.CountDocuments(ctx, bson.M{"_id": eventId, "userId": userId})
+	if err != nil || count == 0 {
+		c.JSON(404, gin.H{"msg": "No event found ❌"})
+		return
+	}
+
+	payload, err := utils.VerifyTicket(input.Code, eventId.Hex())
+	if errors.Is(err, utils.ErrTicketKeyMissing) {
+		c.JSON(503, gin.H{"msg": "Ticketing is not set up on this server"})
+		return
+	}
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "Invalid ticket❌", "code": "TICKET_INVALID"})
+		return
+	}
+
+	// only an unused, live ticket matches, so two scanners can't both let it in
+	var booking models.Booking
+	err = bookingCollection.FindOneAndUpdate(ctx, bson.M{
+		"eventId":     eventId,
+		"ticketId":    payload.TicketId,
+		"status":      models.BookingConfirmed,
+		"active":      true,
+		"checkedInAt": bson.M{"$exists": false},
+	}, bson.M{"$set": bson.M{
+		"checkedInAt": checkedInAt,
+		"checkedInBy": userId,
+		"updated_at":  time.Now(),
+	}}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&booking)
+	if err == nil {
+		c.JSON(200, gin.H{"msg": "Checked in✅", "ticketType": booking.TicketType, "booking": booking})
+		return
+	}
+
+	// say why it was turned away
+	if err := bookingCollection.FindOne(ctx, bson.M{"eventId": eventId, "ticketId": payload.TicketId}).Decode(&booking); err != nil {
+		c.JSON(404, gin.H{"msg": "Ticket not found❌", "code": "TICKET_NOT_FOUND"})
+		return
+	}
+	if !booking.CheckedInAt.IsZero() {
+		c.JSON(409, gin.H{"msg": "Ticket already used⚠️", "code": "TICKET_USED", "checkedInAt": booking.CheckedInAt})
+		return
+	}
+	c.JSON(410, gin.H{"msg": "Ticket was cancelled❌", "code": "TICKET_CANCELLED"})
+}
+
+// the event's ticket public key, scanners verify codes with it when offline
+func GetTicketKey(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userId := c.MustGet("userId").(primitive.ObjectID)
+	eventId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "Invalid param Id"})
+		return
+	}
+
+	count, err := eventsCollection.CountDocuments(ctx, bson.M{"_id": eventId, "userId": userId})
+	if err != nil || count == 0 {
+		c.JSON(404, gin.H{"msg": "No event found ❌"})
+		return
+	}
+
+	pub, err := utils.EventTicketPublicKey(eventId.Hex())
+	if err != nil {
+		c.JSON(503, gin.H{"msg": "Ticketing is not set up on this server"})
+		return
+	}
+	pemKey, _ := utils.EventTicketPublicKeyPEM(eventId.Hex())
+
+	c.JSON(200, gin.H{
+		"msg":       "Ticket verification key🔑",
+		"eventId":   eventId.Hex(),
+		"alg":       "Ed25519",
+		"publicKey": base64.RawURLEncoding.EncodeToString(pub),
+		"pem":       pemKey,
+		// codes are TKT1.<base64url json payload>.<base64url signature>, signed over "TKT1.<payload>"
+		"format": utils.TicketCodePrefix + "<payload>.<signature>",
+	})
+}
+
+// myTicket loads the caller's confirmed booking for :id and signs its code, answers errors itself
+func myTicket(ctx context.Context, c *gin.Context) (*models.Booking, string, bool) {
+	userId := c.MustGet("userId").(primitive.ObjectID)
+	eventId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "Invalid param Id"})
+		return nil, "", false
+	}
+
+	filter := bson.M{"eventId": eventId, "userId": userId, "active": true, "status": models.BookingConfirmed}
+
+	var booking models.Booking
+	if err := bookingCollection.FindOne(ctx, filter).Decode(&booking); err != nil {
+		c.JSON(404, gin.H{"msg": "You have no confirmed RSVP for this event"})
+		return nil, "", false
+	}
+
+	issuedAt := booking.ConfirmedAt
+	if issuedAt.IsZero() {
+		issuedAt = booking.CreatedAt
+	}
+	code, err := utils.SignTicket(utils.TicketPayload{
+		TicketId: booking.TicketId,
+		EventId:  eventId.Hex(),
+		Type:     booking.TicketType,
+		IssuedAt: issuedAt.Unix(),
+	})
+	if err != nil {
+		c.JSON(503, gin.H{"msg": "Ticketing is not set up on this server"})
+		return nil, "", false
+	}
+	return &booking, code, true
+}
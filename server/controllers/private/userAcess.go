@@ -168,50 +168,22 @@ func DeleteOneUser(c *gin.Context) {
 		return
 	}
 
+	// deleted account => no token of it should work anymore
+	_ = utils.RevokeAccountAccess(ctx, sessionCollection, mongoId, "user")
+
 	c.JSON(200, gin.H{
 		"msg": "Your Profile Deleted💔",
 	})
 
 }
 
-// User Logout API
+// User Logout API, only the current device is logged out
 func UserLogout(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	type LogoutInput struct {
-		RefreshToken string `json:"refreshToken"`
-	}
-
-	var input LogoutInput
-	if err := c.ShouldBindJSON(&input); err != nil || input.RefreshToken == "" {
-		c.JSON(400, gin.H{"msg": "Invalid request"})
-		return
-	}
-
-	// Find user by refresh token
-	var user models.User
-	err := userCollection.FindOne(ctx, bson.M{"refreshToken": input.RefreshToken}).Decode(&user)
-	if err != nil {
-		c.JSON(401, gin.H{"msg": "Invalid refresh token"})
-		return
-	}
-
-	// Invalidate refresh token
-	_, err = userCollection.UpdateByID(ctx, user.ID, bson.M{
-		"$set": bson.M{
-			"refreshToken":  "",
-			"refreshExpiry": time.Time{},
-			"updated_at":    time.Now(),
-		},
-	})
-
-	if err != nil {
-		c.JSON(500, gin.H{"msg": "Could not logout, try again"})
+	if !logoutCurrentSession(c) {
 		return
 	}
 
 	c.JSON(200, gin.H{
 		"msg": "User logged out successfully ✅",
 	})
-}
+}
\ No newline at end of file
@@ -9,24 +9,24 @@ import (
 	"time"
 
 	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/config"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/middleware"
 	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
 	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"golang.org/x/crypto/bcrypt"
 )
 
 var adminCollection *mongo.Collection
+var invitationCollection *mongo.Collection
 
 func AdminCollect() {
 	adminCollection = utils.MongoClient.Database("Event_Booking").Collection("admin")
+	invitationCollection = utils.MongoClient.Database("Event_Booking").Collection("invitations")
 }
 
-// jwt key and url
-var adminJwtKey = []byte(config.AppConfig.JWTKEY)
+// url
 var adminUrl = config.AppConfig.URL
 
 // generate token func
@@ -50,6 +50,8 @@ func AdminSignUp(c *gin.Context) {
 		Phone     string `json:"phone" form:"phone"`
 		Language  string `json:"language" form:"language"`
 		Location  string `json:"location" form:"location"`
+		// admins can only join through an invitation from another admin
+		InviteToken string `json:"inviteToken" form:"inviteToken"`
 	}
 
 	// bind into json
@@ -60,9 +62,11 @@ func AdminSignUp(c *gin.Context) {
 		})
 		return
 	}
+	// emails are stored lowercase, same as invites and bootstrap
+	inputAdmin.Email = strings.ToLower(strings.TrimSpace(inputAdmin.Email))
 
 	// validations
-	if inputAdmin.AdminName == "" || inputAdmin.Email == "" || inputAdmin.Password == "" || inputAdmin.Phone == "" || inputAdmin.Language == "" || inputAdmin.Location == "" {
+	if inputAdmin.AdminName == "" || inputAdmin.Email == "" || inputAdmin.Password == "" || inputAdmin.Phone == "" || inputAdmin.Language == "" || inputAdmin.Location == "" || inputAdmin.InviteToken == "" {
 		c.JSON(400, gin.H{
 			"msg": "Invalid Request, please fill all fields⚠️",
 		})
@@ -76,10 +80,8 @@ func AdminSignUp(c *gin.Context) {
 		return
 	}
 
-	if len(inputAdmin.Password) < 6 {
-		c.JSON(400, gin.H{
-			"msg": "Invalid password length",
-		})
+	// password policy
+	if !checkNewPassword(c, inputAdmin.Password, "", nil) {
 		return
 	}
 
@@ -107,7 +109,7 @@ func AdminSignUp(c *gin.Context) {
 	}
 
 	// hash the pass
-	hashPass, err := bcrypt.GenerateFromPassword([]byte(inputAdmin.Password), 10)
+	hashPass, err := utils.HashPassword(inputAdmin.Password)
 	if err != nil {
 		c.JSON(400, gin.H{
 			"msg": "couldn't hash pass",
@@ -115,43 +117,50 @@ func AdminSignUp(c *gin.Context) {
 		return
 	}
 
-	emailToken := GenerateToken(8)
-	phoneToken := GenerateToken(8)
+	// use up the invitation, it must be for this email, unused and not expired
+	var invite models.Invitation
+	err = invitationCollection.FindOneAndUpdate(ctx, bson.M{
+		"tokenHash": utils.HashToken(inputAdmin.InviteToken),
+		"email":     inputAdmin.Email,
+		"used":      false,
+		"revoked":   false,
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}, bson.M{
+		"$set": bson.M{
+			"used":   true,
+			"usedAt": time.Now(),
+		},
+	}).Decode(&invite)
+	if err != nil {
+		c.JSON(403, gin.H{
+			"msg": "Invalid or expired invitation⚠️",
+		})
+		return
+	}
 
-	// create new var and send email
+	// create new var, the invite already proved the email
 	var newAdmin models.Admin
 
 	newAdmin.ID = primitive.NewObjectID()
+	middleware.SetAuditActor(c, newAdmin.ID, "admin", inputAdmin.Email)
 	newAdmin.Role = "admin"
 	newAdmin.AdminName = inputAdmin.AdminName
 	newAdmin.Email = inputAdmin.Email
-	newAdmin.Password = string(hashPass)
+	newAdmin.Password = hashPass
 	newAdmin.Location = inputAdmin.Location
 	newAdmin.Language = inputAdmin.Language
 	newAdmin.Phone = inputAdmin.Phone
-	newAdmin.AdminVerified.Email = false
-	newAdmin.AdminVerifyToken.Email = emailToken
-	newAdmin.AdminVerifyToken.Phone = phoneToken
+	newAdmin.AdminVerified.Email = true
 	newAdmin.CreatedAt = time.Now()
 	newAdmin.UpdatedAt = time.Now()
 
-	// send email
-	go func() {
-
-		emailData := utils.EmailData{
-			From:    "Team Ivents Plannerz🎉",
-			To:      inputAdmin.Email,
-			Subject: "Email Verification",
-			Html:    fmt.Sprintf(`<a href="%s/api/public/admin/emailverify/%s">Verify email</a>`, adminUrl, emailToken),
-		}
-
-		_ = utils.SendEmail(emailData)
-
-	}()
-
-	// push into db
+	// push into db, on failure the invite goes back so it can be used again
 	_, err = adminCollection.InsertOne(ctx, newAdmin)
 	if err != nil {
+		_, _ = invitationCollection.UpdateByID(ctx, invite.ID, bson.M{
+			"$set":   bson.M{"used": false},
+			"$unset": bson.M{"usedAt": ""},
+		})
 		c.JSON(400, gin.H{
 			"msg": "Db error",
 		})
@@ -159,7 +168,7 @@ func AdminSignUp(c *gin.Context) {
 	}
 
 	c.JSON(200, gin.H{
-		"msg": "Admin Signed Up🎉, Verify Your Email and then login✅",
+		"msg": "Admin Signed Up🎉, you can login now✅",
 	})
 
 }
@@ -181,6 +190,7 @@ func EmailVerifyAdmin(c *gin.Context) {
 		})
 		return
 	}
+	middleware.SetAuditActor(c, admin.ID, "admin", admin.Email)
 
 	if admin.AdminVerified.Email {
 		c.JSON(200, gin.H{
@@ -189,10 +199,18 @@ func EmailVerifyAdmin(c *gin.Context) {
 		return
 	}
 
+	if admin.AdminVerifyToken.EmailExpiry.Before(time.Now()) {
+		c.JSON(400, gin.H{
+			"msg":  "Verification link expired, ask for a new one",
+			"code": "EMAIL_VERIFY_EXPIRED",
+		})
+		return
+	}
+
 	// update
 	update := bson.M{
 		"$set": bson.M{
-			"adminverified.emailVerified":       true,
+			"adminVerified.emailVerified":       true,
 			"adminVerifyToken.emailVerifyToken": nil,
 			"updated_at":                        time.Now(),
 		}}
@@ -211,6 +229,76 @@ func EmailVerifyAdmin(c *gin.Context) {
 	})
 }
 
+func sendAdminVerifyEmail(email string, emailToken string) {
+	emailData := utils.EmailData{
+		From:    "Team Ivents Plannerz🎉",
+		To:      email,
+		Subject: "Email Verification",
+		Html:    fmt.Sprintf(`<a href="%s/api/public/admin/emailverify/%s">Verify email</a><p>This link expires in %d hours.</p>`, adminUrl, emailToken, int(emailVerifyTTL.Hours())),
+	}
+
+	_ = utils.SendEmail(emailData)
+}
+
+// resend verification api
+func AdminResendVerification(c *gin.Context) {
+	// ctx
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	type ResendInput struct {
+		Email string `json:"email" form:"email"`
+	}
+
+	var input ResendInput
+	if err := c.ShouldBindJSON(&input); err != nil || !strings.Contains(input.Email, "@") {
+		c.JSON(400, gin.H{
+			"msg": "invalid Email",
+		})
+		return
+	}
+	input.Email = strings.ToLower(strings.TrimSpace(input.Email))
+
+	// one mail per email every couple minutes
+	ok, err := utils.RedisClient.SetNX(ctx, "verify:resend:admin:"+input.Email, "1", resendVerifyCooldown).Result()
+	if err != nil {
+		c.JSON(500, gin.H{
+			"msg": "redis error",
+		})
+		return
+	}
+	if !ok {
+		c.JSON(429, gin.H{
+			"msg": "Please wait before asking for a new link⚠️",
+		})
+		return
+	}
+
+	var admin models.Admin
+	err = adminCollection.FindOne(ctx, bson.M{"email": input.Email}).Decode(&admin)
+	middleware.SetAuditActor(c, admin.ID, "admin", input.Email)
+	if err == nil && !admin.AdminVerified.Email {
+		emailToken := GenerateToken(8)
+		_, err = adminCollection.UpdateByID(ctx, admin.ID, bson.M{
+			"$set": bson.M{
+				"adminVerifyToken.emailVerifyToken":  emailToken,
+				"adminVerifyToken.emailVerifyExpiry": time.Now().Add(emailVerifyTTL),
+				"updated_at":                         time.Now(),
+			}})
+		if err != nil {
+			c.JSON(400, gin.H{
+				"msg": "db error",
+			})
+			return
+		}
+		go sendAdminVerifyEmail(admin.Email, emailToken)
+	}
+
+	c.JSON(200, gin.H{
+		"msg": "If that account needs verification, a new link is sent✅",
+	})
+}
+
 func AdminSignIn(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -225,39 +313,64 @@ func AdminSignIn(c *gin.Context) {
 		c.JSON(400, gin.H{"msg": "invalid request"})
 		return
 	}
+	input.Email = strings.ToLower(strings.TrimSpace(input.Email))
+
+	if input.Email == "" || input.Password == "" {
+		c.JSON(400, gin.H{"msg": "fill all fields!❌"})
+		return
+	}
+
+	guardKey := utils.LoginAccountKey("admin", input.Email)
+	if !loginAllowed(c, guardKey) {
+		return
+	}
 
 	var admin models.Admin
 	err := adminCollection.FindOne(ctx, bson.M{"email": input.Email}).Decode(&admin)
+	middleware.SetAuditActor(c, admin.ID, "admin", input.Email)
 	if err != nil {
-		c.JSON(400, gin.H{"msg": "admin not found"})
+		_, _ = utils.VerifyPassword(dummyPasswordHash, input.Password)
+		loginFailed(c, guardKey, "")
+		return
+	}
+
+	ok, needsRehash := utils.VerifyPassword(admin.Password, input.Password)
+	if !ok {
+		loginFailed(c, guardKey, admin.Email)
+		return
+	}
+	utils.ResetLoginFailures(guardKey)
+
+	// legacy bcrypt hash => argon2id, now that we know the password
+	if needsRehash {
+		upgradePasswordHash(ctx, adminCollection, admin.ID, admin.Password, input.Password)
+	}
+
+	if !admin.AdminVerified.Email {
+		c.JSON(403, gin.H{"msg": "Please verify your email before login⚠️", "code": "EMAIL_NOT_VERIFIED"})
+		return
+	}
+
+	// 2FA admins need their code, and when 2FA is required the rest must enroll first
+	enroll := !admin.MFA.Enabled && adminMFARequired(ctx)
+	if admin.MFA.Enabled || enroll {
+		mfaChallenge(c, admin.ID, "admin", enroll)
 		return
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(admin.Password), []byte(input.Password))
+	// one session per device
+	session, refreshToken, err := createSession(ctx, c, admin.ID, "admin")
 	if err != nil {
-		c.JSON(400, gin.H{"msg": "invalid password"})
+		c.JSON(400, gin.H{"msg": "db error"})
 		return
 	}
 
 	// Access token
-	accessToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"id":    admin.ID.Hex(),
-		"role":  admin.Role,
-		"email": admin.Email,
-		"exp":   time.Now().Add(5 * time.Hour).Unix(),
-	}).SignedString([]byte(config.AppConfig.JWTKEY))
-
-	// Refresh token
-	refreshToken := GenerateUserToken(32)
-	refreshExpiry := time.Now().Add(7 * 24 * time.Hour)
-
-	_, _ = adminCollection.UpdateByID(ctx, admin.ID, bson.M{
-		"$set": bson.M{
-			"refreshToken":  refreshToken,
-			"refreshExpiry": refreshExpiry,
-			"updated_at":    time.Now(),
-		},
-	})
+	accessToken, err := utils.GenerateAccessToken(admin.ID.Hex(), "admin", admin.Role, admin.Email, session.ID.Hex())
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "token generation failed"})
+		return
+	}
 
 	c.JSON(200, gin.H{
 		"msg":          "Admin logged in successfully",
@@ -266,6 +379,7 @@ func AdminSignIn(c *gin.Context) {
 	})
 }
 
+// admin refresh api, rotates the refresh token and revokes the session on reuse
 func AdminRefreshToken(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -280,36 +394,30 @@ func AdminRefreshToken(c *gin.Context) {
 		return
 	}
 
+	session, refreshToken, err := rotateSession(ctx, c, input.RefreshToken, "admin")
+	if err != nil {
+		refreshErrorResponse(c, err)
+		return
+	}
+
 	var admin models.Admin
-	err := adminCollection.FindOne(ctx, bson.M{"refreshToken": input.RefreshToken}).Decode(&admin)
-	if err != nil || admin.RefreshExpiry.IsZero() || admin.RefreshExpiry.Before(time.Now()) {
+	err = adminCollection.FindOne(ctx, bson.M{"_id": session.AccountId}).Decode(&admin)
+	if err != nil {
 		c.JSON(401, gin.H{"msg": "Invalid or expired refresh token"})
 		return
 	}
+	middleware.SetAuditActor(c, admin.ID, "admin", admin.Email)
 
 	// New access token
-	accessToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"id":    admin.ID.Hex(),
-		"role":  admin.Role,
-		"email": admin.Email,
-		"exp":   time.Now().Add(5 * time.Hour).Unix(),
-	}).SignedString([]byte(config.AppConfig.JWTKEY))
-
-	// Refresh token
-	refreshToken := GenerateToken(32) // <-- yahan GenerateUserToken nahi use karna
-	refreshExpiry := time.Now().Add(7 * 24 * time.Hour)
-
-	_, _ = adminCollection.UpdateByID(ctx, admin.ID, bson.M{
-		"$set": bson.M{
-			"refreshToken":  refreshToken,
-			"refreshExpiry": refreshExpiry,
-			"updated_at":    time.Now(),
-		},
-	})
+	accessToken, err := utils.GenerateAccessToken(admin.ID.Hex(), "admin", admin.Role, admin.Email, session.ID.Hex())
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "token generation failed"})
+		return
+	}
 
 	// Response
 	c.JSON(200, gin.H{
-		"msg":          "Admin logged in successfully",
+		"msg":          "New access token generated",
 		"token":        accessToken,
 		"refreshToken": refreshToken,
 	})
@@ -324,9 +432,11 @@ func AdminChangePass(c *gin.Context) {
 
 	// type struct
 	type AdminSignIn struct {
-		Email       string `json:"email" form:"email"`
-		Oldpassword string `json:"oldpassword" form:"oldpassword"`
-		Newpassword string `json:"newpassword" form:"newpassword"`
+		Email        string `json:"email" form:"email"`
+		Oldpassword  string `json:"oldpassword" form:"oldpassword"`
+		Newpassword  string `json:"newpassword" form:"newpassword"`
+		Code         string `json:"code" form:"code"`
+		RecoveryCode string `json:"recoveryCode" form:"recoveryCode"`
 	}
 
 	// bind into json
@@ -353,34 +463,51 @@ func AdminChangePass(c *gin.Context) {
 		return
 	}
 
-	if len(inputAdmin.Newpassword) < 6 {
-		c.JSON(400, gin.H{
-			"msg": "invalid new pass length",
-		})
+	// same guard and answers as sign in, the old password can't be guessed here instead
+	guardKey := utils.LoginAccountKey("admin", inputAdmin.Email)
+	if !loginAllowed(c, guardKey) {
 		return
 	}
 
 	// find email in db
 	var admin models.Admin
 	err := adminCollection.FindOne(ctx, bson.M{"email": inputAdmin.Email}).Decode(&admin)
+	middleware.SetAuditActor(c, admin.ID, "admin", inputAdmin.Email)
 	if err != nil {
-		c.JSON(400, gin.H{
-			"msg": "invalid request, no email found",
-		})
+		_, _ = utils.VerifyPassword(dummyPasswordHash, inputAdmin.Oldpassword)
+		loginFailed(c, guardKey, "")
 		return
 	}
 
 	// compare old pass
-	err = bcrypt.CompareHashAndPassword([]byte(admin.Password), []byte(inputAdmin.Oldpassword))
-	if err != nil {
-		c.JSON(400, gin.H{
-			"msg": "invalid old password",
-		})
+	if ok, _ := utils.VerifyPassword(admin.Password, inputAdmin.Oldpassword); !ok {
+		loginFailed(c, guardKey, admin.Email)
+		return
+	}
+
+	// with 2FA on the password alone isn't enough
+	if admin.MFA.Enabled {
+		if inputAdmin.Code == "" && inputAdmin.RecoveryCode == "" {
+			c.JSON(400, gin.H{
+				"msg":         "Enter the code from your authenticator app🔐",
+				"mfaRequired": true,
+			})
+			return
+		}
+		if !checkSecondFactor(ctx, adminCollection, admin.ID, admin.MFA, inputAdmin.Code, inputAdmin.RecoveryCode) {
+			loginFailed(c, guardKey, admin.Email)
+			return
+		}
+	}
+	utils.ResetLoginFailures(guardKey)
+
+	// policy + not one of the last passwords
+	if !checkNewPassword(c, inputAdmin.Newpassword, admin.Password, admin.PasswordHistory) {
 		return
 	}
 
 	// hash new pass
-	hashPass, err := bcrypt.GenerateFromPassword([]byte(inputAdmin.Newpassword), 10)
+	fields, err := newPasswordFields(inputAdmin.Newpassword, admin.Password, admin.PasswordHistory)
 	if err != nil {
 		c.JSON(400, gin.H{
 			"msg": "hashing failed",
@@ -390,10 +517,8 @@ func AdminChangePass(c *gin.Context) {
 
 	// update db
 	update := bson.M{
-		"$set": bson.M{
-			"password":   string(hashPass),
-			"updated_at": time.Now(),
-		}}
+		"$set": fields,
+	}
 	// update db
 	_, err = adminCollection.UpdateByID(ctx, admin.ID, update)
 	if err != nil {
@@ -403,13 +528,21 @@ func AdminChangePass(c *gin.Context) {
 		return
 	}
 
+	// old tokens must not outlive the old password
+	if err := utils.RevokeAccountAccess(ctx, sessionCollection, admin.ID, "admin"); err != nil {
+		c.JSON(500, gin.H{
+			"msg": "password changed but couldn't revoke old sessions",
+		})
+		return
+	}
+
 	c.JSON(200, gin.H{
 		"msg": "Password Changed Successfully!✅",
 	})
 
 }
 
-// forgot passs api
+// forgot passs api, emails a single use expiring reset link
 func AdminForgotPass(c *gin.Context) {
 	// ctx
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -428,6 +561,7 @@ func AdminForgotPass(c *gin.Context) {
 		})
 		return
 	}
+	inputAdmin.Email = strings.ToLower(strings.TrimSpace(inputAdmin.Email))
 
 	// validations
 	if !strings.Contains(inputAdmin.Email, "@") || inputAdmin.Email == "" {
@@ -437,31 +571,26 @@ func AdminForgotPass(c *gin.Context) {
 		return
 	}
 
-	// find user in db
+	// find admin in db, same answer whether it exists or not
 	var admin models.Admin
 	err := adminCollection.FindOne(ctx, bson.M{"email": inputAdmin.Email}).Decode(&admin)
+	middleware.SetAuditActor(c, admin.ID, "admin", inputAdmin.Email)
 	if err != nil {
-		c.JSON(400, gin.H{
-			"msg": "invalid db error",
+		c.JSON(200, gin.H{
+			"msg": "If that email exists, a reset link is sent to it✅✨",
 		})
 		return
 	}
 
-	// generate temp pass
-	tempPass := GenerateToken(8)
-	hashNewPass, err := bcrypt.GenerateFromPassword([]byte(tempPass), 10)
-	if err != nil {
-		c.JSON(400, gin.H{
-			"msg": "invalid request, couldnt hash pass",
-		})
-		return
-	}
+	// generate reset token, only its hash goes to db
+	resetToken := GenerateToken(32)
 
 	// update the db
 	update := bson.M{
 		"$set": bson.M{
-			"password":   string(hashNewPass),
-			"updated_at": time.Now(),
+			"passwordReset.tokenHash": utils.HashToken(resetToken),
+			"passwordReset.expiry":    time.Now().Add(passwordResetTTL),
+			"updated_at":              time.Now(),
 		}}
 
 	// update db
@@ -477,13 +606,105 @@ func AdminForgotPass(c *gin.Context) {
 	emailData := utils.EmailData{
 		From:    "Team Ivents Plannerz🎉",
 		To:      inputAdmin.Email,
-		Subject: "Email Verification",
-		Html:    fmt.Sprintf(`<h2>Your Temporary password is <strong>%s</strong></h2>`, tempPass),
+		Subject: "Reset Password Request",
+		Html: fmt.Sprintf(`<h2>Reset your password</h2><p><a href="%s/admin/reset-password?token=%s">Reset password</a></p><p>This link expires in %d minutes and works only once. If you didn't ask for it, ignore this email.</p>`,
+			config.AppConfig.FrontendURL, resetToken, int(passwordResetTTL.Minutes())),
 	}
 
 	_ = utils.SendEmail(emailData)
 
 	c.JSON(200, gin.H{
-		"msg": "Temporary email sent to ur mail✅✨",
+		"msg": "If that email exists, a reset link is sent to it✅✨",
+	})
+}
+
+// reset pass api, new password in exchange for the reset token
+func AdminResetPass(c *gin.Context) {
+	// ctx
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// type struct
+	type AdminResetPass struct {
+		Token       string `json:"token" form:"token"`
+		Newpassword string `json:"newpassword" form:"newpassword"`
+	}
+
+	// bind
+	var inputAdmin AdminResetPass
+	if err := c.ShouldBindJSON(&inputAdmin); err != nil {
+		c.JSON(400, gin.H{
+			"msg": "invalid request",
+		})
+		return
+	}
+
+	// validations
+	if inputAdmin.Token == "" || inputAdmin.Newpassword == "" {
+		c.JSON(400, gin.H{
+			"msg": "invalid request, fill all fields",
+		})
+		return
+	}
+
+	// find admin by token hash
+	tokenHash := utils.HashToken(inputAdmin.Token)
+	var admin models.Admin
+	err := adminCollection.FindOne(ctx, bson.M{
+		"passwordReset.tokenHash": tokenHash,
+		"passwordReset.expiry":    bson.M{"$gt": time.Now()},
+	}).Decode(&admin)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"msg": "invalid or expired reset token",
+		})
+		return
+	}
+	middleware.SetAuditActor(c, admin.ID, "admin", admin.Email)
+
+	// policy + not one of the last passwords
+	if !checkNewPassword(c, inputAdmin.Newpassword, admin.Password, admin.PasswordHistory) {
+		return
+	}
+
+	// hash new pass
+	fields, err := newPasswordFields(inputAdmin.Newpassword, admin.Password, admin.PasswordHistory)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"msg": "hashing failed",
+		})
+		return
+	}
+	fields["passwordReset.tokenHash"] = ""
+	fields["passwordReset.expiry"] = time.Time{}
+
+	// update db, filter on the token hash so the link works only once
+	res, err := adminCollection.UpdateOne(ctx, bson.M{"_id": admin.ID, "passwordReset.tokenHash": tokenHash}, bson.M{
+		"$set": fields,
+	})
+	if err != nil {
+		c.JSON(400, gin.H{
+			"msg": "invalid db error",
+		})
+		return
+	}
+
+	if res.ModifiedCount == 0 {
+		c.JSON(400, gin.H{
+			"msg": "invalid or expired reset token",
+		})
+		return
+	}
+
+	// every old session goes
+	if err := utils.RevokeAccountAccess(ctx, sessionCollection, admin.ID, "admin"); err != nil {
+		c.JSON(500, gin.H{
+			"msg": "password reset but couldn't revoke old sessions",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"msg": "Password Reset Successfully, please login again✅",
 	})
 }
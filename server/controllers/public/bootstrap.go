@@ -0,0 +1,70 @@
+package public
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BootstrapInput is what the cli needs to create the first super admin
+type BootstrapInput struct {
+	AdminName string
+	Email     string
+	Password  string
+	Phone     string
+	Language  string
+	Location  string
+}
+
+// BootstrapSuperAdmin creates the very first admin, it refuses once any admin exists.
+// every other admin joins through an invitation
+func BootstrapSuperAdmin(input BootstrapInput) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if input.AdminName == "" || input.Email == "" || input.Password == "" || input.Phone == "" {
+		return errors.New("name, email, password and phone are required")
+	}
+	if !strings.Contains(input.Email, "@") {
+		return errors.New("invalid email")
+	}
+	if err := utils.CheckPasswordPolicy(input.Password); err != nil {
+		return err
+	}
+
+	count, err := adminCollection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("an admin already exists, use invitations instead")
+	}
+
+	hashPass, err := utils.HashPassword(input.Password)
+	if err != nil {
+		return err
+	}
+
+	var newAdmin models.Admin
+	newAdmin.ID = primitive.NewObjectID()
+	newAdmin.Role = "admin"
+	newAdmin.SuperAdmin = true
+	newAdmin.AdminName = input.AdminName
+	newAdmin.Email = strings.ToLower(input.Email)
+	newAdmin.Password = hashPass
+	newAdmin.Phone = input.Phone
+	newAdmin.Language = input.Language
+	newAdmin.Location = input.Location
+	newAdmin.AdminVerified.Email = true
+	newAdmin.CreatedAt = time.Now()
+	newAdmin.UpdatedAt = time.Now()
+
+	_, err = adminCollection.InsertOne(ctx, newAdmin)
+	return err
+}
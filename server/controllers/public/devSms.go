@@ -0,0 +1,17 @@
+package public
+
+import (
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/config"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// FakeSMSOutbox shows what the fake sms sink "sent", only exists while the fake sink is on
+func FakeSMSOutbox(c *gin.Context) {
+	if !config.AppConfig.Phone.FakeSink {
+		c.JSON(404, gin.H{"msg": "Not found"})
+		return
+	}
+
+	c.JSON(200, gin.H{"msg": "Fake SMS outbox📱", "messages": utils.FakeSMSOutbox(c.Query("to"))})
+}
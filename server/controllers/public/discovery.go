@@ -0,0 +1,397 @@
+package public
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	publicEventCollection    *mongo.Collection
+	publicFunctionCollection *mongo.Collection
+)
+
+// discovery paging and caching
+const (
+	discoveryMaxLimit = 50
+	discoveryCacheTTL = 60 * time.Second
+)
+
+// ?sort= values, soonest first by default
+var discoverySorts = map[string]bson.D{
+	"startAt":  {{Key: "startAt", Value: 1}, {Key: "_id", Value: 1}},
+	"-startAt": {{Key: "startAt", Value: -1}, {Key: "_id", Value: -1}},
+	"newest":   {{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+}
+
+func DiscoveryCollect() {
+	publicEventCollection = utils.MongoClient.Database("Event_Booking").Collection("events")
+	publicFunctionCollection = utils.MongoClient.Database("Event_Booking").Collection("functions")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	index := mongo.IndexModel{Keys: bson.D{{Key: "ispublic", Value: 1}, {Key: "status", Value: 1}, {Key: "startAt", Value: 1}}}
+	for _, coll := range []*mongo.Collection{publicEventCollection, publicFunctionCollection} {
+		if _, err := coll.Indexes().CreateOne(ctx, index); err != nil {
+			fmt.Println("⚠️ couldn't create discovery index on", coll.Name(), err)
+		}
+	}
+	utils.SearchTextIndexes(ctx, publicEventCollection, publicFunctionCollection)
+}
+
+// PublicEvent is what anyone can see of a public event, no owner or guest details
+type PublicEvent struct {
+	ID          primitive.ObjectID  `json:"id"`
+	EventName   string              `json:"eventname"`
+	EventType   string              `json:"eventtype"`
+	Description string              `json:"eventdesc"`
+	ImageUrl    string              `json:"imageUrl"`
+	Location    string              `json:"location"`
+	StartAt     time.Time           `json:"startAt"`
+	EndAt       time.Time           `json:"endAt"`
+	Timezone    string              `json:"timezone"`
+	Coordinates *models.GeoPoint    `json:"coordinates,omitempty"`
+	DistanceKm  *float64            `json:"distanceKm,omitempty"` // only on near/bbox queries
+	Capacity    int                 `json:"capacity"`
+	SeatsLeft   int                 `json:"seatsLeft"`
+	TicketTypes []PublicTicketType  `json:"ticketTypes,omitempty"`
+	RRule       string              `json:"rrule,omitempty"`       // set on repeating events
+	Occurrences []models.Occurrence `json:"occurrences,omitempty"` // only with from and to
+}
+
+type PublicTicketType struct {
+	Name       string    `json:"name"`
+	Remaining  int       `json:"remaining"`
+	SalesEndAt time.Time `json:"salesEndAt,omitempty"`
+}
+
+// PublicFunction is what anyone can see of a public function
+type PublicFunction struct {
+	ID          primitive.ObjectID `json:"id"`
+	FuncName    string             `json:"funcname"`
+	FuncType    string             `json:"functype"`
+	Description string             `json:"funcdes"`
+	ImageUrl    string             `json:"imageUrl"`
+	Location    string             `json:"location"`
+	StartAt     time.Time          `json:"startAt"`
+	EndAt       time.Time          `json:"endAt"`
+	Timezone    string             `json:"timezone"`
+	Coordinates *models.GeoPoint   `json:"coordinates,omitempty"`
+	DistanceKm  *float64           `json:"distanceKm,omitempty"` // only on near/bbox queries
+}
+
+func toPublicEvent(e models.Event) PublicEvent {
+	out := PublicEvent{
+		ID:          e.ID,
+		EventName:   e.EventName,
+		EventType:   e.EventtType,
+		Description: e.EventDescription,
+		ImageUrl:    e.ImageUrl,
+		Location:    e.Location,
+		StartAt:     e.StartAt,
+		EndAt:       e.EndAt,
+		Timezone:    e.Timezone,
+		Coordinates: e.Coordinates,
+		Capacity:    e.EventAttendence,
+		SeatsLeft:   max(0, e.EventAttendence-e.ConfirmedCount),
+		Occurrences: e.Occurrences,
+	}
+	if e.Recurrence != nil {
+		out.RRule = e.Recurrence.RRule
+	}
+	for _, t := range e.TicketTypes {
+		out.TicketTypes = append(out.TicketTypes, PublicTicketType{Name: t.Name, Remaining: t.Remaining, SalesEndAt: t.SalesEndAt})
+	}
+	return out
+}
+
+func toPublicFunction(f models.Function) PublicFunction {
+	return PublicFunction{
+		ID:          f.ID,
+		FuncName:    f.FuncName,
+		FuncType:    f.FuncType,
+		Description: f.FuncDesc,
+		ImageUrl:    f.ImageUrl,
+		Location:    f.Location,
+		StartAt:     f.StartAt,
+		EndAt:       f.EndAt,
+		Timezone:    f.Timezone,
+		Coordinates: f.Coordinates,
+	}
+}
+
+// discoveryPage is the list response, cached as is
+type discoveryPage[T any] struct {
+	Msg     string `json:"msg"`
+	Items   []T    `json:"items"`
+	Page    int    `json:"page"`
+	Limit   int    `json:"limit"`
+	Total   int64  `json:"total"`
+	HasNext bool   `json:"hasNext"`
+	HasPrev bool   `json:"hasPrev"`
+	Source  string `json:"source"`
+}
+
+// discoveryQuery is a parsed ?type=&location=&from=&to=&sort=&page=&limit=
+type discoveryQuery struct {
+	Filter bson.M
+	Sort   bson.D
+	Page   int
+	Limit  int
+	Geo    *utils.GeoQuery // set => nearest first instead of Sort
+	// window to list occurrences in, zero unless both from and to are given
+	From     time.Time
+	To       time.Time
+	CacheKey string
+}
+
+// parseDiscoveryQuery builds the filter for public, upcoming items. answers 400 itself
+func parseDiscoveryQuery(c *gin.Context, kind string, typeField string) (*discoveryQuery, bool) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	if limit > discoveryMaxLimit {
+		limit = discoveryMaxLimit
+	}
+
+	sortName := c.DefaultQuery("sort", "startAt")
+	sort, ok := discoverySorts[sortName]
+	if !ok {
+		c.JSON(400, gin.H{"msg": "sort must be startAt, -startAt or newest"})
+		return nil, false
+	}
+
+	// upcoming: not over yet, even if the scheduler hasn't caught up
+	now := time.Now()
+	filter := bson.M{
+		"ispublic": "public",
+		"status":   models.StatusUpcoming,
+		"$or":      utils.EndsAfter(now),
+	}
+
+	types := []string{}
+	for _, t := range strings.Split(c.Query("type"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+	if len(types) > 0 {
+		filter[typeField] = bson.M{"$in": types}
+	}
+
+	location := strings.TrimSpace(c.Query("location"))
+	if len(location) > 100 {
+		c.JSON(400, gin.H{"msg": "location is too long"})
+		return nil, false
+	}
+	if location != "" {
+		filter["location"] = bson.M{"$regex": regexp.QuoteMeta(location), "$options": "i"}
+	}
+
+	from, to, err := utils.ParseRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(400, gin.H{"msg": err.Error()})
+		return nil, false
+	}
+	if !from.IsZero() && from.After(now) {
+		filter["$or"] = utils.EndsAfter(from)
+	}
+	if !to.IsZero() {
+		filter["startAt"] = bson.M{"$lt": to}
+	}
+	var windowFrom, windowTo time.Time
+	if !from.IsZero() && !to.IsZero() {
+		windowFrom, windowTo = from, to
+		if windowFrom.Before(now) {
+			windowFrom = now
+		}
+	}
+
+	geo, err := utils.ParseGeoQuery(c.Query("near"), c.Query("radius"), c.Query("bbox"))
+	if err != nil {
+		c.JSON(400, gin.H{"msg": err.Error()})
+		return nil, false
+	}
+	geoKey := ""
+	if geo != nil {
+		geoKey = geo.CacheKey()
+	}
+
+	// same query => same key, the values are hashed so the key stays short
+	normalized := fmt.Sprintf("%s|%s|%s|%s|%s|%d|%d|%s", strings.Join(types, ","), strings.ToLower(location), c.Query("from"), c.Query("to"), sortName, page, limit, geoKey)
+
+	return &discoveryQuery{
+		Filter:   filter,
+		Sort:     sort,
+		Page:     page,
+		Limit:    limit,
+		Geo:      geo,
+		From:     windowFrom,
+		To:       windowTo,
+		CacheKey: "public:" + kind + ":" + utils.HashToken(normalized),
+	}, true
+}
+
+// discoveryDoc is a stored document, plus its distance on geo queries
+type discoveryDoc[M any] struct {
+	Doc      M       `bson:",inline"`
+	Distance float64 `bson:"distance"`
+}
+
+// discoveryFind loads one page, by the requested sort or nearest first
+func discoveryFind[M any](ctx context.Context, coll *mongo.Collection, query *discoveryQuery, skip int) ([]discoveryDoc[M], int64, error) {
+	var cursor *mongo.Cursor
+	var total int64
+	var err error
+
+	if query.Geo != nil {
+		if total, err = coll.CountDocuments(ctx, query.Geo.CountFilter(query.Filter)); err != nil {
+			return nil, 0, err
+		}
+		cursor, err = coll.Aggregate(ctx, query.Geo.Pipeline(query.Filter, int64(skip), int64(query.Limit)))
+	} else {
+		if total, err = coll.CountDocuments(ctx, query.Filter); err != nil {
+			return nil, 0, err
+		}
+		opts := options.Find().SetSkip(int64(skip)).SetLimit(int64(query.Limit)).SetSort(query.Sort)
+		cursor, err = coll.Find(ctx, query.Filter, opts)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []discoveryDoc[M]
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, 0, err
+	}
+	return docs, total, nil
+}
+
+// discoveryFromCache serves a cached page, reports whether it did
+func discoveryFromCache[T any](ctx context.Context, c *gin.Context, key string) bool {
+	cached, err := utils.RedisClient.Get(ctx, key).Result()
+	if err != nil {
+		return false
+	}
+	var page discoveryPage[T]
+	if json.Unmarshal([]byte(cached), &page) != nil {
+		return false
+	}
+	page.Source = "redis"
+	c.JSON(200, page)
+	return true
+}
+
+func discoveryToCache[T any](ctx context.Context, key string, page discoveryPage[T]) {
+	page.Source = ""
+	data, _ := json.Marshal(page)
+	_ = utils.RedisClient.Set(ctx, key, data, discoveryCacheTTL).Err()
+}
+
+// browse public upcoming events, no login needed
+func GetPublicEvents(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query, ok := parseDiscoveryQuery(c, "events", "eventtype")
+	if !ok {
+		return
+	}
+	if discoveryFromCache[PublicEvent](ctx, c, query.CacheKey) {
+		return
+	}
+
+	skip := (query.Page - 1) * query.Limit
+	events, total, err := discoveryFind[models.Event](ctx, publicEventCollection, query, skip)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+
+	items := make([]PublicEvent, 0, len(events))
+	for _, e := range events {
+		if !query.To.IsZero() {
+			e.Doc.Occurrences = utils.Occurrences(&e.Doc, query.From, query.To)
+		}
+		item := toPublicEvent(e.Doc)
+		if query.Geo != nil {
+			item.DistanceKm = &e.Distance
+		}
+		items = append(items, item)
+	}
+
+	page := discoveryPage[PublicEvent]{
+		Msg:     "Public Events✨",
+		Items:   items,
+		Page:    query.Page,
+		Limit:   query.Limit,
+		Total:   total,
+		HasNext: int64(skip+query.Limit) < total,
+		HasPrev: query.Page > 1,
+		Source:  "db",
+	}
+	discoveryToCache(ctx, query.CacheKey, page)
+	c.JSON(200, page)
+}
+
+// browse public upcoming functions, no login needed
+func GetPublicFunctions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query, ok := parseDiscoveryQuery(c, "functions", "functype")
+	if !ok {
+		return
+	}
+	if discoveryFromCache[PublicFunction](ctx, c, query.CacheKey) {
+		return
+	}
+
+	skip := (query.Page - 1) * query.Limit
+	functions, total, err := discoveryFind[models.Function](ctx, publicFunctionCollection, query, skip)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+
+	items := make([]PublicFunction, 0, len(functions))
+	for _, f := range functions {
+		item := toPublicFunction(f.Doc)
+		if query.Geo != nil {
+			item.DistanceKm = &f.Distance
+		}
+		items = append(items, item)
+	}
+
+	page := discoveryPage[PublicFunction]{
+		Msg:     "Public Functions✨",
+		Items:   items,
+		Page:    query.Page,
+		Limit:   query.Limit,
+		Total:   total,
+		HasNext: int64(skip+query.Limit) < total,
+		HasPrev: query.Page > 1,
+		Source:  "db",
+	}
+	discoveryToCache(ctx, query.CacheKey, page)
+	c.JSON(200, page)
+}
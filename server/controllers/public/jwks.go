@@ -0,0 +1,12 @@
+package public
+
+import (
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS publishes our token verification keys so other services don't need any secret
+func JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(200, utils.JWKS())
+}
@@ -0,0 +1,53 @@
+package public
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// same answer for unknown email and wrong password, nobody can probe which emails exist
+const invalidCredentialsMsg = "invalid email or password"
+
+// hash of a random string, compared against when the email doesn't exist so both paths take as long
+var dummyPasswordHash, _ = utils.HashPassword(GenerateToken(16))
+
+// loginAllowed answers 429 when this account or ip has to wait
+func loginAllowed(c *gin.Context, accountKey string) bool {
+	wait, locked, err := utils.CheckLogin(accountKey, c.ClientIP())
+	if err != nil {
+		c.JSON(500, gin.H{"msg": "redis error"})
+		return false
+	}
+	if wait > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		c.JSON(429, gin.H{"msg": utils.LoginRetryMessage(wait, locked)})
+		return false
+	}
+	return true
+}
+
+// loginFailed counts the failure and answers with the generic credentials error
+func loginFailed(c *gin.Context, accountKey string, ownerEmail string) {
+	recordLoginFailure(c, accountKey, ownerEmail)
+	c.JSON(400, gin.H{"msg": invalidCredentialsMsg})
+}
+
+// recordLoginFailure counts a failed password or code and mails the owner when it just locked their account
+func recordLoginFailure(c *gin.Context, accountKey string, ownerEmail string) {
+	lockedNow, _ := utils.RecordLoginFailure(accountKey, c.ClientIP())
+	if lockedNow && ownerEmail != "" {
+		go func() {
+			emailData := utils.EmailData{
+				From:    "Team Ivents Plannerz🎉",
+				To:      ownerEmail,
+				Subject: "Your account was locked",
+				Html: fmt.Sprintf(`<h2>Too many failed sign in attempts</h2><p>Your account was locked for a while after repeated wrong passwords or codes, last one from IP %s.</p><p>If this wasn't you, reset your password.</p>`,
+					c.ClientIP()),
+			}
+			_ = utils.SendEmail(emailData)
+		}()
+	}
+}
@@ -0,0 +1,323 @@
+package public
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/config"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/middleware"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// passwordless login limits
+const (
+	magicLinkTTL         = 15 * time.Minute
+	loginOTPTTL          = 5 * time.Minute
+	loginOTPDigits       = 6
+	loginOTPMaxAttempts  = 5
+	passwordlessCooldown = time.Minute
+)
+
+const passwordlessSentMsg = "If that account exists, a login code/link is on its way✉️"
+
+// step 1: email a single use login link, same answer whether the email exists or not
+func UserMagicLink(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if !config.AppConfig.Passwordless.MagicLink {
+		c.JSON(404, gin.H{"msg": "Magic link login is not enabled"})
+		return
+	}
+
+	type MagicLinkInput struct {
+		Email string `json:"email" form:"email"`
+	}
+
+	var input MagicLinkInput
+	if err := c.ShouldBindJSON(&input); err != nil || !strings.Contains(input.Email, "@") {
+		c.JSON(400, gin.H{"msg": "invalid Email"})
+		return
+	}
+
+	var user models.User
+	err := userCollection.FindOne(ctx, bson.M{"email": input.Email}).Decode(&user)
+	middleware.SetAuditActor(c, user.ID, "user", input.Email)
+	if err != nil || user.Suspended {
+		c.JSON(200, gin.H{"msg": passwordlessSentMsg})
+		return
+	}
+
+	// one link a minute per account
+	ok, err := utils.RedisClient.SetNX(ctx, "login:magic:cooldown:"+user.ID.Hex(), "1", passwordlessCooldown).Result()
+	if err != nil {
+		c.JSON(500, gin.H{"msg": "redis error"})
+		return
+	}
+	if !ok {
+		c.JSON(200, gin.H{"msg": passwordlessSentMsg})
+		return
+	}
+
+	token := GenerateUserToken(32)
+	err = utils.RedisClient.Set(ctx, "login:magic:"+utils.HashToken(token), user.ID.Hex(), magicLinkTTL).Err()
+	if err != nil {
+		c.JSON(500, gin.H{"msg": "redis error"})
+		return
+	}
+
+	go func() {
+		emailData := utils.EmailData{
+			From:    "Team Ivents Plannerz🎉",
+			To:      user.Email,
+			Subject: "Your login link",
+			Html: fmt.Sprintf(`<h2>Login to Ivents Plannerz</h2><p><a href="%s/magic-login?token=%s">Login now</a></p><p>This link expires in %d minutes and works only once. If you didn't ask for it, ignore this email.</p>`,
+				config.AppConfig.FrontendURL, token, int(magicLinkTTL.Minutes())),
+		}
+		_ = utils.SendEmail(emailData)
+	}()
+
+	c.JSON(200, gin.H{"msg": passwordlessSentMsg})
+}
+
+// step 2: the page behind the link posts the token here (a POST so mail scanners opening the link don't use it up)
+func UserMagicLinkVerify(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if !config.AppConfig.Passwordless.MagicLink {
+		c.JSON(404, gin.H{"msg": "Magic link login is not enabled"})
+		return
+	}
+
+	type MagicVerifyInput struct {
+		Token string `json:"token" form:"token"`
+	}
+
+	var input MagicVerifyInput
+	if err := c.ShouldBindJSON(&input); err != nil || input.Token == "" {
+		c.JSON(400, gin.H{"msg": "invalid request"})
+		return
+	}
+
+	// GetDel => the link works exactly once
+	userHex, err := utils.RedisClient.GetDel(ctx, "login:magic:"+utils.HashToken(input.Token)).Result()
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "invalid or expired login link"})
+		return
+	}
+
+	userId, err := primitive.ObjectIDFromHex(userHex)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "invalid or expired login link"})
+		return
+	}
+
+	var user models.User
+	if err := userCollection.FindOne(ctx, bson.M{"_id": userId}).Decode(&user); err != nil {
+		c.JSON(400, gin.H{"msg": "invalid or expired login link"})
+		return
+	}
+	middleware.SetAuditActor(c, user.ID, "user", user.Email)
+
+	// opening the link proves they own the email
+	if !user.Userverified.Email {
+		_, _ = userCollection.UpdateByID(ctx, user.ID, bson.M{"$set": bson.M{
+			"userverified.emailVerified":        true,
+			"userverifytoken.emailVerifyToken":  "",
+			"userverifytoken.emailVerifyExpiry": time.Time{},
+		}})
+		user.Userverified.Email = true
+	}
+
+	completeUserLogin(ctx, c, &user)
+}
+
+// step 1 (sms variant): text a one time code to a verified phone
+func UserPhoneLogin(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if !config.AppConfig.Passwordless.PhoneOTP {
+		c.JSON(404, gin.H{"msg": "Phone login is not enabled"})
+		return
+	}
+
+	type PhoneLoginInput struct {
+		Phone string `json:"phone" form:"phone"`
+	}
+
+	var input PhoneLoginInput
+	if err := c.ShouldBindJSON(&input); err != nil || len(input.Phone) < 10 {
+		c.JSON(400, gin.H{"msg": "Invalid phone number"})
+		return
+	}
+
+	user, err := findUserByVerifiedPhone(ctx, input.Phone)
+	if err != nil || user.Suspended {
+		c.JSON(200, gin.H{"msg": passwordlessSentMsg})
+		return
+	}
+	middleware.SetAuditActor(c, user.ID, "user", user.Email)
+
+	ok, err := utils.RedisClient.SetNX(ctx, "login:otp:cooldown:"+user.ID.Hex(), "1", passwordlessCooldown).Result()
+	if err != nil {
+		c.JSON(500, gin.H{"msg": "redis error"})
+		return
+	}
+	if !ok {
+		c.JSON(200, gin.H{"msg": passwordlessSentMsg})
+		return
+	}
+
+	otp := utils.GenerateOTP(loginOTPDigits)
+
+	// new code => fresh attempts
+	pipe := utils.RedisClient.TxPipeline()
+	pipe.Set(ctx, "login:otp:"+user.ID.Hex(), utils.HashToken(otp), loginOTPTTL)
+	pipe.Del(ctx, "login:otp:attempts:"+user.ID.Hex())
+	if _, err := pipe.Exec(ctx); err != nil {
+		c.JSON(500, gin.H{"msg": "redis error"})
+		return
+	}
+
+	smsData := utils.SMSData{
+		To:   user.Phone,
+		Body: fmt.Sprintf("Your Ivents Plannerz login code is %s. It expires in %d minutes. Never share it.", otp, int(loginOTPTTL.Minutes())),
+	}
+	if err := utils.SendSMS(smsData); err != nil {
+		c.JSON(500, gin.H{"msg": "Couldn't send sms, try again"})
+		return
+	}
+
+	c.JSON(200, gin.H{"msg": passwordlessSentMsg})
+}
+
+// step 2 (sms variant): code in, tokens out
+func UserPhoneLoginVerify(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if !config.AppConfig.Passwordless.PhoneOTP {
+		c.JSON(404, gin.H{"msg": "Phone login is not enabled"})
+		return
+	}
+
+	type PhoneVerifyInput struct {
+		Phone string `json:"phone" form:"phone"`
+		Code  string `json:"code" form:"code"`
+	}
+
+	var input PhoneVerifyInput
+	if err := c.ShouldBindJSON(&input); err != nil || len(input.Code) != loginOTPDigits {
+		c.JSON(400, gin.H{"msg": "invalid request"})
+		return
+	}
+
+	user, err := findUserByVerifiedPhone(ctx, input.Phone)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "Invalid or expired code❌"})
+		return
+	}
+	middleware.SetAuditActor(c, user.ID, "user", user.Email)
+
+	otpKey := "login:otp:" + user.ID.Hex()
+	attemptsKey := "login:otp:attempts:" + user.ID.Hex()
+
+	// count the attempt first so parallel guesses can't skip the limit
+	attempts, err := utils.RedisClient.Incr(ctx, attemptsKey).Result()
+	if err != nil {
+		c.JSON(500, gin.H{"msg": "redis error"})
+		return
+	}
+	if attempts == 1 {
+		utils.RedisClient.Expire(ctx, attemptsKey, loginOTPTTL)
+	}
+	if attempts > loginOTPMaxAttempts {
+		utils.RedisClient.Del(ctx, otpKey)
+		c.JSON(429, gin.H{"msg": "Too many wrong attempts, ask for a new code⚠️"})
+		return
+	}
+
+	otpHash, err := utils.RedisClient.Get(ctx, otpKey).Result()
+	if err != nil || subtle.ConstantTimeCompare([]byte(utils.HashToken(input.Code)), []byte(otpHash)) != 1 {
+		c.JSON(400, gin.H{"msg": "Invalid or expired code❌"})
+		return
+	}
+
+	// only the request that deletes the code gets to log in
+	deleted, err := utils.RedisClient.Del(ctx, otpKey).Result()
+	if err != nil || deleted == 0 {
+		c.JSON(400, gin.H{"msg": "Invalid or expired code❌"})
+		return
+	}
+	utils.RedisClient.Del(ctx, attemptsKey)
+
+	if !user.Userverified.Email {
+		c.JSON(403, gin.H{"msg": "Please verify your email before login⚠️", "code": "EMAIL_NOT_VERIFIED"})
+		return
+	}
+
+	completeUserLogin(ctx, c, user)
+}
+
+// findUserByVerifiedPhone finds the one user with this verified phone, shared numbers don't qualify
+func findUserByVerifiedPhone(ctx context.Context, phone string) (*models.User, error) {
+	cursor, err := userCollection.Find(ctx, bson.M{
+		"phone":                      phone,
+		"userverified.phoneVerified": true,
+	}, options.Find().SetLimit(2))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	if len(users) != 1 {
+		return nil, fmt.Errorf("no single user with this phone")
+	}
+	return &users[0], nil
+}
+
+// completeUserLogin is the end of every user login once the first factor passed:
+// suspension and 2FA checks, then the usual access/refresh pair for a new session
+func completeUserLogin(ctx context.Context, c *gin.Context, user *models.User) {
+	if user.Suspended {
+		c.JSON(403, gin.H{"msg": "Your account is suspended⚠️"})
+		return
+	}
+
+	if user.MFA.Enabled {
+		mfaChallenge(c, user.ID, "user", false)
+		return
+	}
+
+	session, refreshToken, err := createSession(ctx, c, user.ID, "user")
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+
+	accessToken, err := utils.GenerateAccessToken(user.ID.Hex(), "user", user.Role, user.Email, session.ID.Hex())
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "token generation failed"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"msg":          "Logged in successfully!✨",
+		"token":        accessToken,
+		"refreshToken": refreshToken,
+	})
+}
@@ -0,0 +1,326 @@
+package public
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/config"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/middleware"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var settingsCollection *mongo.Collection
+
+func SettingsCollect() {
+	settingsCollection = utils.MongoClient.Database("Event_Booking").Collection("settings")
+}
+
+// wrong codes allowed per mfa pending token, the account wide limit is the login guard's (see MFAGuardKey)
+const mfaMaxAttempts = 5
+
+// recovery codes handed out on enrollment
+const mfaRecoveryCodes = 10
+
+// adminMFARequired tells if admins must have 2FA before they get any token
+func adminMFARequired(ctx context.Context) bool {
+	var settings models.Settings
+	if err := settingsCollection.FindOne(ctx, bson.M{"_id": "global"}).Decode(&settings); err != nil {
+		return false
+	}
+	return settings.RequireAdminMFA
+}
+
+// mfaChallenge answers a correct password with an mfa pending token instead of real tokens
+func mfaChallenge(c *gin.Context, accountId primitive.ObjectID, accountType string, enroll bool) {
+	mfaToken, err := utils.GenerateMFAToken(accountId.Hex(), accountType, enroll)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "token generation failed"})
+		return
+	}
+
+	msg := "Enter the code from your authenticator app🔐"
+	if enroll {
+		msg = "Two factor auth is required for admins, set it up to continue🔐"
+	}
+
+	c.JSON(200, gin.H{
+		"msg":           msg,
+		"mfaRequired":   true,
+		"mfaEnrollment": enroll,
+		"mfaToken":      mfaToken,
+	})
+}
+
+// parsePendingMFA verifies an mfa pending token, it must not be used up and has a limited number of tries
+func parsePendingMFA(ctx context.Context, tokenStr string, accountType string) (*utils.MFAClaims, error) {
+	claims, err := utils.ParseMFAToken(tokenStr)
+	if err != nil || claims.AccountType != accountType {
+		return nil, errors.New("invalid or expired mfa token")
+	}
+
+	denied, err := utils.IsTokenDenied(claims.Jti, "", claims.Id, claims.IssuedAt)
+	if err != nil || denied {
+		return nil, errors.New("invalid or expired mfa token")
+	}
+
+	attemptsKey := "mfa:attempts:" + claims.Jti
+	attempts, err := utils.RedisClient.Incr(ctx, attemptsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	utils.RedisClient.ExpireAt(ctx, attemptsKey, claims.Exp)
+	if attempts > mfaMaxAttempts {
+		_ = utils.DenyToken(claims.Jti, claims.Exp)
+		return nil, errors.New("too many attempts, please login again")
+	}
+
+	return claims, nil
+}
+
+// checkSecondFactor accepts either a totp code (each time step once) or an unused recovery code
+func checkSecondFactor(ctx context.Context, collection *mongo.Collection, accountId primitive.ObjectID, mfa models.MFA, code string, recoveryCode string) bool {
+	if code != "" {
+		step, ok := utils.VerifyTOTP(mfa.Secret, code)
+		if !ok {
+			return false
+		}
+		fresh, err := utils.ClaimTOTPStep(accountId.Hex(), step)
+		return err == nil && fresh
+	}
+
+	if recoveryCode != "" {
+		// $pull makes every recovery code single use
+		hash := utils.HashRecoveryCode(recoveryCode)
+		res, err := collection.UpdateOne(ctx, bson.M{"_id": accountId, "mfa.recoveryCodes": hash}, bson.M{
+			"$pull": bson.M{"mfa.recoveryCodes": hash},
+		})
+		return err == nil && res.ModifiedCount == 1
+	}
+
+	return false
+}
+
+type mfaVerifyInput struct {
+	MFAToken     string `json:"mfaToken"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+// user mfa verify api, swaps the mfa pending token + code for real tokens
+func UserMFAVerify(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var input mfaVerifyInput
+	if err := c.ShouldBindJSON(&input); err != nil || input.MFAToken == "" || (input.Code == "" && input.RecoveryCode == "") {
+		c.JSON(400, gin.H{"msg": "Invalid request"})
+		return
+	}
+
+	claims, err := parsePendingMFA(ctx, input.MFAToken, "user")
+	if err != nil {
+		c.JSON(401, gin.H{"msg": err.Error()})
+		return
+	}
+
+	guardKey := utils.MFAGuardKey("user", claims.Id)
+	if !loginAllowed(c, guardKey) {
+		return
+	}
+
+	userId, _ := primitive.ObjectIDFromHex(claims.Id)
+	var user models.User
+	if err := userCollection.FindOne(ctx, bson.M{"_id": userId}).Decode(&user); err != nil || user.Suspended {
+		c.JSON(401, gin.H{"msg": "invalid or expired mfa token"})
+		return
+	}
+	middleware.SetAuditActor(c, user.ID, "user", user.Email)
+
+	if !user.MFA.Enabled || !checkSecondFactor(ctx, userCollection, user.ID, user.MFA, input.Code, input.RecoveryCode) {
+		recordLoginFailure(c, guardKey, user.Email)
+		c.JSON(400, gin.H{"msg": "Invalid code❌"})
+		return
+	}
+	utils.ResetLoginFailures(guardKey)
+
+	// mfa token is single use
+	_ = utils.DenyToken(claims.Jti, claims.Exp)
+
+	session, refreshToken, err := createSession(ctx, c, user.ID, "user")
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+
+	accessToken, err := utils.GenerateAccessToken(user.ID.Hex(), "user", user.Role, user.Email, session.ID.Hex())
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "token generation failed"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"msg":          "Logged in successfully!✨",
+		"token":        accessToken,
+		"refreshToken": refreshToken,
+	})
+}
+
+// admin mfa setup api, for admins forced to enroll before their first token
+func AdminMFASetup(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	type SetupInput struct {
+		MFAToken string `json:"mfaToken"`
+	}
+
+	var input SetupInput
+	if err := c.ShouldBindJSON(&input); err != nil || input.MFAToken == "" {
+		c.JSON(400, gin.H{"msg": "Invalid request"})
+		return
+	}
+
+	claims, err := parsePendingMFA(ctx, input.MFAToken, "admin")
+	if err != nil {
+		c.JSON(401, gin.H{"msg": err.Error()})
+		return
+	}
+	if !claims.Enroll {
+		c.JSON(400, gin.H{"msg": "Two factor auth is already set up"})
+		return
+	}
+
+	adminId, _ := primitive.ObjectIDFromHex(claims.Id)
+	var admin models.Admin
+	if err := adminCollection.FindOne(ctx, bson.M{"_id": adminId}).Decode(&admin); err != nil {
+		c.JSON(401, gin.H{"msg": "invalid or expired mfa token"})
+		return
+	}
+	middleware.SetAuditActor(c, admin.ID, "admin", admin.Email)
+	if admin.MFA.Enabled {
+		c.JSON(400, gin.H{"msg": "Two factor auth is already set up"})
+		return
+	}
+
+	secret := utils.GenerateTOTPSecret()
+	_, err = adminCollection.UpdateByID(ctx, admin.ID, bson.M{
+		"$set": bson.M{
+			"mfa.pendingSecret": secret,
+			"updated_at":        time.Now(),
+		},
+	})
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"msg":             "Scan this in your authenticator app, then verify a code🔐",
+		"secret":          secret,
+		"provisioningUri": utils.TOTPProvisioningURI(secret, config.AppConfig.AppName, admin.Email),
+	})
+}
+
+// admin mfa verify api, finishes a forced enrollment or a normal 2FA login
+func AdminMFAVerify(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var input mfaVerifyInput
+	if err := c.ShouldBindJSON(&input); err != nil || input.MFAToken == "" || (input.Code == "" && input.RecoveryCode == "") {
+		c.JSON(400, gin.H{"msg": "Invalid request"})
+		return
+	}
+
+	claims, err := parsePendingMFA(ctx, input.MFAToken, "admin")
+	if err != nil {
+		c.JSON(401, gin.H{"msg": err.Error()})
+		return
+	}
+
+	guardKey := utils.MFAGuardKey("admin", claims.Id)
+	if !loginAllowed(c, guardKey) {
+		return
+	}
+
+	adminId, _ := primitive.ObjectIDFromHex(claims.Id)
+	var admin models.Admin
+	if err := adminCollection.FindOne(ctx, bson.M{"_id": adminId}).Decode(&admin); err != nil {
+		c.JSON(401, gin.H{"msg": "invalid or expired mfa token"})
+		return
+	}
+	middleware.SetAuditActor(c, admin.ID, "admin", admin.Email)
+
+	var recoveryCodes []string
+	switch {
+	case admin.MFA.Enabled:
+		if !checkSecondFactor(ctx, adminCollection, admin.ID, admin.MFA, input.Code, input.RecoveryCode) {
+			recordLoginFailure(c, guardKey, admin.Email)
+			c.JSON(400, gin.H{"msg": "Invalid code❌"})
+			return
+		}
+	case claims.Enroll && admin.MFA.PendingSecret != "":
+		// first code confirms the enrollment
+		step, ok := utils.VerifyTOTP(admin.MFA.PendingSecret, input.Code)
+		if !ok {
+			recordLoginFailure(c, guardKey, admin.Email)
+			c.JSON(400, gin.H{"msg": "Invalid code❌"})
+			return
+		}
+		_, _ = utils.ClaimTOTPStep(admin.ID.Hex(), step)
+
+		var hashes []string
+		recoveryCodes, hashes = utils.GenerateRecoveryCodes(mfaRecoveryCodes)
+		_, err = adminCollection.UpdateByID(ctx, admin.ID, bson.M{
+			"$set": bson.M{
+				"mfa.enabled":       true,
+				"mfa.secret":        admin.MFA.PendingSecret,
+				"mfa.pendingSecret": "",
+				"mfa.recoveryCodes": hashes,
+				"mfa.enabledAt":     time.Now(),
+				"updated_at":        time.Now(),
+			},
+		})
+		if err != nil {
+			c.JSON(400, gin.H{"msg": "db error"})
+			return
+		}
+	default:
+		c.JSON(400, gin.H{"msg": "Set up two factor auth first"})
+		return
+	}
+
+	// mfa token is single use
+	_ = utils.DenyToken(claims.Jti, claims.Exp)
+	utils.ResetLoginFailures(guardKey)
+
+	session, refreshToken, err := createSession(ctx, c, admin.ID, "admin")
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+
+	accessToken, err := utils.GenerateAccessToken(admin.ID.Hex(), "admin", admin.Role, admin.Email, session.ID.Hex())
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "token generation failed"})
+		return
+	}
+
+	resp := gin.H{
+		"msg":          "Admin logged in successfully",
+		"token":        accessToken,
+		"refreshToken": refreshToken,
+	}
+	if recoveryCodes != nil {
+		resp["recoveryCodes"] = recoveryCodes
+		resp["msg"] = fmt.Sprintf("Two factor auth enabled✅ Save these %d recovery codes, they are shown only once", len(recoveryCodes))
+	}
+	c.JSON(200, resp)
+}
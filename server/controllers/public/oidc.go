@@ -0,0 +1,203 @@
+package public
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/config"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/middleware"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// how long the browser has to come back from the provider
+const oidcStateTTL = 10 * time.Minute
+
+var (
+	errEmailNotVerifiedByProvider = errors.New("Your identity provider didn't verify your email⚠️")
+	errNoAccountForSSO            = errors.New("No account uses this email, sign up first⚠️")
+)
+
+// what we keep in redis between login and callback
+type oidcState struct {
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+// sso login api, sends the browser to the identity provider (authorization code + PKCE)
+func UserOIDCLogin(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if !config.AppConfig.OIDC.Enabled {
+		c.JSON(404, gin.H{"msg": "SSO login is not enabled"})
+		return
+	}
+
+	provider, err := utils.OIDCDiscover()
+	if err != nil {
+		c.JSON(502, gin.H{"msg": "Identity provider unreachable"})
+		return
+	}
+
+	state := GenerateUserToken(16)
+	nonce := GenerateUserToken(16)
+	verifier, challenge := utils.NewPKCE()
+	data, _ := json.Marshal(oidcState{Nonce: nonce, Verifier: verifier})
+
+	err = utils.RedisClient.Set(ctx, "oidc:state:"+state, data, oidcStateTTL).Err()
+	if err != nil {
+		c.JSON(500, gin.H{"msg": "redis error"})
+		return
+	}
+
+	authURL := utils.OIDCAuthURL(provider, state, nonce, challenge)
+
+	// spa clients can ask for the url instead of a redirect
+	if c.Query("redirect") == "false" {
+		c.JSON(200, gin.H{"msg": "Go to this url to login", "url": authURL})
+		return
+	}
+	c.Redirect(302, authURL)
+}
+
+// sso callback api, verifies the id_token and issues our usual access/refresh pair
+func UserOIDCCallback(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	if !config.AppConfig.OIDC.Enabled {
+		c.JSON(404, gin.H{"msg": "SSO login is not enabled"})
+		return
+	}
+
+	if errMsg := c.Query("error"); errMsg != "" {
+		c.JSON(400, gin.H{"msg": "SSO login failed: " + errMsg})
+		return
+	}
+
+	code := c.Query("code")
+	state := c.Query("state")
+	if code == "" || state == "" {
+		c.JSON(400, gin.H{"msg": "Invalid request"})
+		return
+	}
+
+	// state is single use
+	raw, err := utils.RedisClient.GetDel(ctx, "oidc:state:"+state).Result()
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "Invalid or expired login state"})
+		return
+	}
+	var saved oidcState
+	if err := json.Unmarshal([]byte(raw), &saved); err != nil {
+		c.JSON(400, gin.H{"msg": "Invalid or expired login state"})
+		return
+	}
+
+	provider, err := utils.OIDCDiscover()
+	if err != nil {
+		c.JSON(502, gin.H{"msg": "Identity provider unreachable"})
+		return
+	}
+
+	idToken, err := utils.OIDCExchangeCode(provider, code, saved.Verifier)
+	if err != nil {
+		c.JSON(401, gin.H{"msg": "Couldn't exchange the login code"})
+		return
+	}
+
+	identity, err := utils.OIDCVerifyIDToken(provider, idToken, saved.Nonce)
+	if err != nil {
+		c.JSON(401, gin.H{"msg": "Invalid id token"})
+		return
+	}
+
+	middleware.SetAuditActor(c, primitive.NilObjectID, "user", identity.Email)
+	user, err := findOrLinkOIDCUser(ctx, identity)
+	if err != nil {
+		c.JSON(403, gin.H{"msg": err.Error()})
+		return
+	}
+	middleware.SetAuditActor(c, user.ID, "user", user.Email)
+
+	// suspension and 2FA still apply to sso logins
+	completeUserLogin(ctx, c, user)
+}
+
+// findOrLinkOIDCUser finds the user already linked to this identity, else links the user
+// with the same (provider verified) email, else creates a new user when AutoProvision is on
+func findOrLinkOIDCUser(ctx context.Context, identity *utils.OIDCIdentity) (*models.User, error) {
+	var user models.User
+	err := userCollection.FindOne(ctx, bson.M{
+		"identities.issuer":  identity.Issuer,
+		"identities.subject": identity.Subject,
+	}).Decode(&user)
+	if err == nil {
+		return &user, nil
+	}
+
+	// linking by email is only safe when the provider vouches for it
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, errEmailNotVerifiedByProvider
+	}
+	email := strings.ToLower(identity.Email)
+
+	link := models.ExternalIdentity{
+		Issuer:   identity.Issuer,
+		Subject:  identity.Subject,
+		Email:    email,
+		LinkedAt: time.Now(),
+	}
+
+	// user emails are stored as typed at sign up, so match them case insensitive
+	caseless := options.FindOneAndUpdate().SetCollation(&options.Collation{Locale: "en", Strength: 2})
+	err = userCollection.FindOneAndUpdate(ctx, bson.M{"email": email}, bson.M{
+		"$push": bson.M{"identities": link},
+		"$set": bson.M{
+			"userverified.emailVerified": true,
+			"updated_at":                 time.Now(),
+		},
+	}, caseless).Decode(&user)
+	if err == nil {
+		return &user, nil
+	}
+	if !config.AppConfig.OIDC.AutoProvision {
+		return nil, errNoAccountForSSO
+	}
+
+	// first time we see this person, sso only account with an unusable random password
+	hashPass, err := utils.HashPassword(GenerateUserToken(32))
+	if err != nil {
+		return nil, err
+	}
+
+	name := identity.Name
+	if name == "" {
+		name = strings.Split(email, "@")[0]
+	}
+
+	var newUser models.User
+	newUser.ID = primitive.NewObjectID()
+	newUser.Role = "user"
+	newUser.Username = name
+	newUser.Email = email
+	newUser.Password = hashPass
+	newUser.Language = "English"
+	newUser.Userverified.Email = true
+	newUser.Identities = []models.ExternalIdentity{link}
+	newUser.Createdat = time.Now()
+	newUser.Updatedat = time.Now()
+
+	if _, err := userCollection.InsertOne(ctx, newUser); err != nil {
+		return nil, err
+	}
+	return &newUser, nil
+}
@@ -0,0 +1,50 @@
+package public
+
+import (
+	"context"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// checkNewPassword runs the policy and history checks, answers 400 itself when the password is refused
+func checkNewPassword(c *gin.Context, password string, currentHash string, history []string) bool {
+	if err := utils.CheckPasswordPolicy(password); err != nil {
+		c.JSON(400, gin.H{"msg": err.Error(), "code": "PASSWORD_POLICY"})
+		return false
+	}
+	if currentHash != "" && utils.PasswordReused(password, currentHash, history) {
+		c.JSON(400, gin.H{"msg": "You used this password recently, pick a new one", "code": "PASSWORD_REUSED"})
+		return false
+	}
+	return true
+}
+
+// newPasswordFields hashes the new password and moves the old hash into history, ready for $set
+func newPasswordFields(password string, currentHash string, history []string) (bson.M, error) {
+	hashPass, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	return bson.M{
+		"password":        hashPass,
+		"passwordHistory": utils.NextPasswordHistory(currentHash, history),
+		"updated_at":      time.Now(),
+	}, nil
+}
+
+// upgradePasswordHash swaps a legacy (bcrypt or old argon2id params) hash for a fresh one after a good login.
+// best effort, filtered on the old hash so a password change in between wins
+func upgradePasswordHash(ctx context.Context, coll *mongo.Collection, id primitive.ObjectID, oldHash string, password string) {
+	hashPass, err := utils.HashPassword(password)
+	if err != nil {
+		return
+	}
+	_, _ = coll.UpdateOne(ctx, bson.M{"_id": id, "password": oldHash}, bson.M{
+		"$set": bson.M{"password": hashPass},
+	})
+}
@@ -0,0 +1,34 @@
+package public
+
+import (
+	"context"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// search the public catalogue, same rules as discovery, no login needed
+func SearchPublic(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query, ok := utils.ParseSearchQuery(c)
+	if !ok {
+		return
+	}
+
+	response, err := utils.Search(ctx, query, "public", bson.M{
+		"ispublic": "public",
+		"status":   models.StatusUpcoming,
+		"$or":      utils.EndsAfter(time.Now()),
+	}, publicEventCollection, publicFunctionCollection, toPublicEvent, toPublicFunction)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "db error"})
+		return
+	}
+
+	c.JSON(200, response)
+}
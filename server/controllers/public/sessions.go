@@ -0,0 +1,128 @@
+package public
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var sessionCollection *mongo.Collection
+
+func SessionCollect() {
+	sessionCollection = utils.MongoClient.Database("Event_Booking").Collection("sessions")
+}
+
+// refresh token lifetime
+const refreshTokenTTL = 7 * 24 * time.Hour
+
+// rotated refresh token hashes kept per session for reuse detection, older ones are dropped
+const maxRotatedTokens = 50
+
+// password reset link lifetime
+const passwordResetTTL = 30 * time.Minute
+
+var (
+	errRefreshInvalid = errors.New("invalid or expired refresh token")
+	errRefreshReuse   = errors.New("refresh token reuse detected")
+)
+
+// createSession stores a new device session and returns its raw refresh token
+func createSession(ctx context.Context, c *gin.Context, accountId primitive.ObjectID, accountType string) (models.Session, string, error) {
+	refreshToken := GenerateToken(32)
+
+	var session models.Session
+	session.ID = primitive.NewObjectID()
+	session.AccountId = accountId
+	session.AccountType = accountType
+	session.RefreshToken = utils.HashToken(refreshToken)
+	session.RotatedTokens = []string{}
+	session.RefreshExpiry = time.Now().Add(refreshTokenTTL)
+	session.UserAgent = c.Request.UserAgent()
+	session.IP = c.ClientIP()
+	session.CreatedAt = time.Now()
+	session.LastUsedAt = time.Now()
+
+	_, err := sessionCollection.InsertOne(ctx, session)
+	if err != nil {
+		return models.Session{}, "", err
+	}
+	return session, refreshToken, nil
+}
+
+// rotateSession swaps the presented refresh token for a new one,
+// presenting an already rotated token revokes the whole session (family)
+func rotateSession(ctx context.Context, c *gin.Context, rawToken string, accountType string) (models.Session, string, error) {
+	tokenHash := utils.HashToken(rawToken)
+
+	var session models.Session
+	err := sessionCollection.FindOne(ctx, bson.M{"refreshToken": tokenHash, "accountType": accountType}).Decode(&session)
+	if err != nil {
+		if sessionCollection.FindOne(ctx, bson.M{"rotatedTokens": tokenHash, "accountType": accountType}).Decode(&session) == nil {
+			revokeSessionFamily(ctx, session.ID)
+			return models.Session{}, "", errRefreshReuse
+		}
+		return models.Session{}, "", errRefreshInvalid
+	}
+
+	if session.Revoked || session.RefreshExpiry.Before(time.Now()) {
+		return models.Session{}, "", errRefreshInvalid
+	}
+
+	// filter on the old hash so two parallel refreshes can't both win
+	refreshToken := GenerateToken(32)
+	res, err := sessionCollection.UpdateOne(ctx, bson.M{"_id": session.ID, "refreshToken": tokenHash, "revoked": false}, bson.M{
+		"$set": bson.M{
+			"refreshToken":  utils.HashToken(refreshToken),
+			"refreshExpiry": time.Now().Add(refreshTokenTTL),
+			"userAgent":     c.Request.UserAgent(),
+			"ip":            c.ClientIP(),
+			"last_used_at":  time.Now(),
+		},
+		"$push": bson.M{"rotatedTokens": bson.M{"$each": bson.A{tokenHash}, "$slice": -maxRotatedTokens}},
+	})
+	if err != nil {
+		return models.Session{}, "", err
+	}
+	if res.ModifiedCount == 0 {
+		// a parallel refresh with the same token rotated it a moment ago, that one got the new pair.
+		// not theft, so the session stays
+		err := sessionCollection.FindOne(ctx, bson.M{"_id": session.ID, "rotatedTokens": tokenHash}).Err()
+		if err != nil && err != mongo.ErrNoDocuments {
+			return models.Session{}, "", err
+		}
+		return models.Session{}, "", errRefreshInvalid
+	}
+
+	return session, refreshToken, nil
+}
+
+// revokeSessionFamily kills a session and with it every refresh token ever rotated in it
+func revokeSessionFamily(ctx context.Context, sessionId primitive.ObjectID) {
+	_, _ = sessionCollection.UpdateByID(ctx, sessionId, bson.M{
+		"$set": bson.M{
+			"revoked":      true,
+			"revokedAt":    time.Now(),
+			"refreshToken": "",
+		},
+	})
+	_ = utils.DenySession(sessionId.Hex())
+}
+
+// refreshErrorResponse maps rotateSession errors to the api response
+func refreshErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errRefreshReuse):
+		c.JSON(401, gin.H{"msg": "Refresh token reuse detected, session revoked. Possible token theft⚠️"})
+	case errors.Is(err, errRefreshInvalid):
+		c.JSON(401, gin.H{"msg": "Invalid or expired refresh token"})
+	default:
+		c.JSON(400, gin.H{"msg": "db error"})
+	}
+}
@@ -9,14 +9,13 @@ import (
 	"time"
 
 	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/config"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/middleware"
 	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
 	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"golang.org/x/crypto/bcrypt"
 )
 
 var userCollection *mongo.Collection
@@ -25,19 +24,20 @@ func UserCollect() {
 	userCollection = utils.MongoClient.Database("Event_Booking").Collection("user")
 }
 
-var userJwtKey = []byte(config.AppConfig.JWTKEY)
 var userUrl = config.AppConfig.URL
 
+// email verification link lifetime and how often it can be resent
+const (
+	emailVerifyTTL       = 24 * time.Hour
+	resendVerifyCooldown = 2 * time.Minute
+)
+
 func GenerateUserToken(length int) string {
 	d := make([]byte, length)
 	_, _ = rand.Read(d)
 	return hex.EncodeToString(d)
 }
 
-func GenerateRefreshToken() string {
-	return GenerateUserToken(32)
-}
-
 // -------------------- SIGN UP --------------------
 func UserSignUp(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -69,8 +69,7 @@ func UserSignUp(c *gin.Context) {
 		return
 	}
 
-	if len(inputUser.Password) < 6 {
-		c.JSON(400, gin.H{"msg": "Invalid password length"})
+	if !checkNewPassword(c, inputUser.Password, "", nil) {
 		return
 	}
 
@@ -89,39 +88,31 @@ func UserSignUp(c *gin.Context) {
 		return
 	}
 
-	hashPass, err := bcrypt.GenerateFromPassword([]byte(inputUser.Password), 10)
+	hashPass, err := utils.HashPassword(inputUser.Password)
 	if err != nil {
 		c.JSON(400, gin.H{"msg": "couldn't hash pass"})
 		return
 	}
 
 	emailToken := GenerateUserToken(8)
-	phoneToken := GenerateUserToken(8)
 
 	var newUser models.User
 	newUser.ID = primitive.NewObjectID()
+	middleware.SetAuditActor(c, newUser.ID, "user", inputUser.Email)
 	newUser.Role = "user"
 	newUser.Username = inputUser.UserName
 	newUser.Email = inputUser.Email
-	newUser.Password = string(hashPass)
+	newUser.Password = hashPass
 	newUser.Location = inputUser.Location
 	newUser.Language = inputUser.Language
 	newUser.Phone = inputUser.Phone
 	newUser.Userverified.Email = false
 	newUser.Userverifytoken.Email = emailToken
-	newUser.Userverifytoken.Phone = phoneToken
+	newUser.Userverifytoken.EmailExpiry = time.Now().Add(emailVerifyTTL)
 	newUser.Createdat = time.Now()
 	newUser.Updatedat = time.Now()
 
-	go func() {
-		emailData := utils.EmailData{
-			From:    "Team Ivents Plannerz🎉",
-			To:      inputUser.Email,
-			Subject: "Email Verification",
-			Html:    fmt.Sprintf(`<a href="%s/api/public/user/emailverify/%s">Verify email</a>`, userUrl, emailToken),
-		}
-		_ = utils.SendEmail(emailData)
-	}()
+	go sendUserVerifyEmail(inputUser.Email, emailToken)
 
 	_, err = userCollection.InsertOne(ctx, newUser)
 	if err != nil {
@@ -144,12 +135,18 @@ func EmailVerifyUser(c *gin.Context) {
 		c.JSON(400, gin.H{"msg": "Invalid Token"})
 		return
 	}
+	middleware.SetAuditActor(c, user.ID, "user", user.Email)
 
 	if user.Userverified.Email {
 		c.JSON(200, gin.H{"msg": "Email Verified already, u can login now!"})
 		return
 	}
 
+	if user.Userverifytoken.EmailExpiry.Before(time.Now()) {
+		c.JSON(400, gin.H{"msg": "Verification link expired, ask for a new one", "code": "EMAIL_VERIFY_EXPIRED"})
+		return
+	}
+
 	update := bson.M{"$set": bson.M{
 		"userverified.emailVerified":      true,
 		"userverifytoken.emailVerifyToken": nil,
@@ -165,6 +162,60 @@ func EmailVerifyUser(c *gin.Context) {
 	c.JSON(200, gin.H{"msg": "email Verified✨🙌"})
 }
 
+func sendUserVerifyEmail(email string, emailToken string) {
+	emailData := utils.EmailData{
+		From:    "Team Ivents Plannerz🎉",
+		To:      email,
+		Subject: "Email Verification",
+		Html:    fmt.Sprintf(`<a href="%s/api/public/user/emailverify/%s">Verify email</a><p>This link expires in %d hours.</p>`, userUrl, emailToken, int(emailVerifyTTL.Hours())),
+	}
+	_ = utils.SendEmail(emailData)
+}
+
+// -------------------- RESEND VERIFICATION --------------------
+func UserResendVerification(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	type ResendInput struct {
+		Email string `json:"email" form:"email"`
+	}
+	var input ResendInput
+	if err := c.ShouldBindJSON(&input); err != nil || !strings.Contains(input.Email, "@") {
+		c.JSON(400, gin.H{"msg": "invalid Email"})
+		return
+	}
+
+	// one mail per email every couple minutes, no matter who asks
+	ok, err := utils.RedisClient.SetNX(ctx, "verify:resend:user:"+input.Email, "1", resendVerifyCooldown).Result()
+	if err != nil {
+		c.JSON(500, gin.H{"msg": "redis error"})
+		return
+	}
+	if !ok {
+		c.JSON(429, gin.H{"msg": "Please wait before asking for a new link⚠️"})
+		return
+	}
+
+	var user models.User
+	err = userCollection.FindOne(ctx, bson.M{"email": input.Email}).Decode(&user)
+	middleware.SetAuditActor(c, user.ID, "user", input.Email)
+	if err == nil && !user.Userverified.Email {
+		emailToken := GenerateUserToken(8)
+		_, err = userCollection.UpdateByID(ctx, user.ID, bson.M{"$set": bson.M{
+			"userverifytoken.emailVerifyToken":  emailToken,
+			"userverifytoken.emailVerifyExpiry": time.Now().Add(emailVerifyTTL),
+			"updated_at":                        time.Now(),
+		}})
+		if err != nil {
+			c.JSON(400, gin.H{"msg": "db error"})
+			return
+		}
+		go sendUserVerifyEmail(user.Email, emailToken)
+	}
+
+	c.JSON(200, gin.H{"msg": "If that account needs verification, a new link is sent✅"})
+}
+
 // -------------------- SIGN IN WITH REFRESH TOKEN --------------------
 func UserSignIn(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -193,55 +244,40 @@ func UserSignIn(c *gin.Context) {
 		return
 	}
 
-	var user models.User
-	err := userCollection.FindOne(ctx, bson.M{"email": inputUser.Email}).Decode(&user)
-	if err != nil {
-		c.JSON(400, gin.H{"msg": "no email found!"})
+	guardKey := utils.LoginAccountKey("user", inputUser.Email)
+	if !loginAllowed(c, guardKey) {
 		return
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(inputUser.Password))
+	var user models.User
+	err := userCollection.FindOne(ctx, bson.M{"email": inputUser.Email}).Decode(&user)
+	middleware.SetAuditActor(c, user.ID, "user", inputUser.Email)
 	if err != nil {
-		c.JSON(400, gin.H{"msg": "invalid password"})
+		_, _ = utils.VerifyPassword(dummyPasswordHash, inputUser.Password)
+		loginFailed(c, guardKey, "")
 		return
 	}
 
-	// Create Access Token
-	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"id":    user.ID.Hex(),
-		"role":  user.Role,
-		"email": user.Email,
-		"exp":   time.Now().Add(5 * time.Hour).Unix(),
-	}).SignedString(userJwtKey)
-	if err != nil {
-		c.JSON(400, gin.H{"msg": "token generation failed"})
+	ok, needsRehash := utils.VerifyPassword(user.Password, inputUser.Password)
+	if !ok {
+		loginFailed(c, guardKey, user.Email)
 		return
 	}
+	utils.ResetLoginFailures(guardKey)
+	if needsRehash {
+		upgradePasswordHash(ctx, userCollection, user.ID, user.Password, inputUser.Password)
+	}
 
-	// Create Refresh Token
-	refreshToken := GenerateRefreshToken()
-	refreshExpiry := time.Now().Add(7 * 24 * time.Hour) // 7 days
-
-	_, err = userCollection.UpdateByID(ctx, user.ID, bson.M{
-		"$set": bson.M{
-			"refreshToken":  refreshToken,
-			"refreshExpiry": refreshExpiry,
-			"updated_at":    time.Now(),
-		},
-	})
-	if err != nil {
-		c.JSON(400, gin.H{"msg": "db error"})
+	if !user.Userverified.Email {
+		c.JSON(403, gin.H{"msg": "Please verify your email before login⚠️", "code": "EMAIL_NOT_VERIFIED"})
 		return
 	}
 
-	c.JSON(200, gin.H{
-		"msg":          "Logged in successfully!✨",
-		"token":        accessToken,
-		"refreshToken": refreshToken,
-	})
+	completeUserLogin(ctx, c, &user)
 }
 
 // -------------------- REFRESH ACCESS TOKEN --------------------
+// every refresh rotates the refresh token, presenting an already rotated one revokes the session
 func RefreshToken(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -255,28 +291,35 @@ func RefreshToken(c *gin.Context) {
 		return
 	}
 
+	session, refreshToken, err := rotateSession(ctx, c, input.RefreshToken, "user")
+	if err != nil {
+		refreshErrorResponse(c, err)
+		return
+	}
+
 	var user models.User
-	err := userCollection.FindOne(ctx, bson.M{"refreshToken": input.RefreshToken}).Decode(&user)
-	if err != nil || user.RefreshExpiry.IsZero() || user.RefreshExpiry.Before(time.Now()) {
+	err = userCollection.FindOne(ctx, bson.M{"_id": session.AccountId}).Decode(&user)
+	if err != nil {
+		c.JSON(401, gin.H{"msg": "Invalid or expired refresh token"})
+		return
+	}
+	middleware.SetAuditActor(c, user.ID, "user", user.Email)
+	if user.Suspended {
 		c.JSON(401, gin.H{"msg": "Invalid or expired refresh token"})
 		return
 	}
 
 	// Generate new access token
-	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"id":    user.ID.Hex(),
-		"role":  user.Role,
-		"email": user.Email,
-		"exp":   time.Now().Add(5 * time.Hour).Unix(),
-	}).SignedString(userJwtKey)
+	accessToken, err := utils.GenerateAccessToken(user.ID.Hex(), "user", user.Role, user.Email, session.ID.Hex())
 	if err != nil {
 		c.JSON(400, gin.H{"msg": "token generation failed"})
 		return
 	}
 
 	c.JSON(200, gin.H{
-		"msg":   "New access token generated",
-		"token": accessToken,
+		"msg":          "New access token generated",
+		"token":        accessToken,
+		"refreshToken": refreshToken,
 	})
 }
 
@@ -284,9 +327,11 @@ func UserChangePass(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	type UserChangePass struct {
-		Email       string `json:"email" form:"email"`
-		Oldpassword string `json:"oldpassword" form:"oldpassword"`
-		Newpassword string `json:"newpassword" form:"newpassword"`
+		Email        string `json:"email" form:"email"`
+		Oldpassword  string `json:"oldpassword" form:"oldpassword"`
+		Newpassword  string `json:"newpassword" form:"newpassword"`
+		Code         string `json:"code" form:"code"`
+		RecoveryCode string `json:"recoveryCode" form:"recoveryCode"`
 	}
 	var inputUser UserChangePass
 	if err := c.ShouldBindJSON(&inputUser); err != nil {
@@ -301,35 +346,58 @@ func UserChangePass(c *gin.Context) {
 		c.JSON(400, gin.H{"msg": "invalid email"})
 		return
 	}
-	if len(inputUser.Newpassword) < 6 {
-		c.JSON(400, gin.H{"msg": "invalid new pass length"})
+	// same guard and answers as sign in, the old password can't be guessed here instead
+	guardKey := utils.LoginAccountKey("user", inputUser.Email)
+	if !loginAllowed(c, guardKey) {
 		return
 	}
 	var user models.User
 	err := userCollection.FindOne(ctx, bson.M{"email": inputUser.Email}).Decode(&user)
+	middleware.SetAuditActor(c, user.ID, "user", inputUser.Email)
 	if err != nil {
-		c.JSON(400, gin.H{"msg": "invalid request, no email found"})
+		_, _ = utils.VerifyPassword(dummyPasswordHash, inputUser.Oldpassword)
+		loginFailed(c, guardKey, "")
 		return
 	}
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(inputUser.Oldpassword))
-	if err != nil {
-		c.JSON(400, gin.H{"msg": "invalid old password"})
+	if ok, _ := utils.VerifyPassword(user.Password, inputUser.Oldpassword); !ok {
+		loginFailed(c, guardKey, user.Email)
 		return
 	}
-	hashPass, err := bcrypt.GenerateFromPassword([]byte(inputUser.Newpassword), 10)
+	// with 2FA on the password alone isn't enough
+	if user.MFA.Enabled {
+		if inputUser.Code == "" && inputUser.RecoveryCode == "" {
+			c.JSON(400, gin.H{"msg": "Enter the code from your authenticator app🔐", "mfaRequired": true})
+			return
+		}
+		if !checkSecondFactor(ctx, userCollection, user.ID, user.MFA, inputUser.Code, inputUser.RecoveryCode) {
+			loginFailed(c, guardKey, user.Email)
+			return
+		}
+	}
+	utils.ResetLoginFailures(guardKey)
+	if !checkNewPassword(c, inputUser.Newpassword, user.Password, user.PasswordHistory) {
+		return
+	}
+	fields, err := newPasswordFields(inputUser.Newpassword, user.Password, user.PasswordHistory)
 	if err != nil {
 		c.JSON(400, gin.H{"msg": "hashing failed"})
 		return
 	}
-	update := bson.M{"$set": bson.M{"password": string(hashPass), "updated_at": time.Now()}}
+	update := bson.M{"$set": fields}
 	_, err = userCollection.UpdateByID(ctx, user.ID, update)
 	if err != nil {
 		c.JSON(400, gin.H{"msg": "invalid db error"})
 		return
 	}
+	// old tokens must not outlive the old password
+	if err := utils.RevokeAccountAccess(ctx, sessionCollection, user.ID, "user"); err != nil {
+		c.JSON(500, gin.H{"msg": "password changed but couldn't revoke old sessions"})
+		return
+	}
 	c.JSON(200, gin.H{"msg": "Password Changed Successfully!✅"})
 }
 
+// step 1: email a single use, expiring reset link. the password itself is not touched here
 func UserForgotPass(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -347,17 +415,18 @@ func UserForgotPass(c *gin.Context) {
 	}
 	var user models.User
 	err := userCollection.FindOne(ctx, bson.M{"email": inputUser.Email}).Decode(&user)
+	middleware.SetAuditActor(c, user.ID, "user", inputUser.Email)
 	if err != nil {
-		c.JSON(400, gin.H{"msg": "invalid db error"})
+		// same answer whether the email exists or not
+		c.JSON(200, gin.H{"msg": "If that email exists, a reset link is sent to it✅✨"})
 		return
 	}
-	tempPass := GenerateUserToken(8)
-	hashNewPass, err := bcrypt.GenerateFromPassword([]byte(tempPass), 10)
-	if err != nil {
-		c.JSON(400, gin.H{"msg": "invalid request, couldnt hash pass"})
-		return
-	}
-	update := bson.M{"$set": bson.M{"password": string(hashNewPass), "updated_at": time.Now()}}
+	resetToken := GenerateUserToken(32)
+	update := bson.M{"$set": bson.M{
+		"passwordReset.tokenHash": utils.HashToken(resetToken),
+		"passwordReset.expiry":    time.Now().Add(passwordResetTTL),
+		"updated_at":              time.Now(),
+	}}
 	_, err = userCollection.UpdateByID(ctx, user.ID, update)
 	if err != nil {
 		c.JSON(400, gin.H{"msg": "invalid db error"})
@@ -367,8 +436,66 @@ func UserForgotPass(c *gin.Context) {
 		From:    "Team Ivents Plannerz🎉",
 		To:      inputUser.Email,
 		Subject: "Reset Password Request",
-		Html:    fmt.Sprintf(`<h2>Your Temporary password is <strong>%s</strong></h2>`, tempPass),
+		Html: fmt.Sprintf(`<h2>Reset your password</h2><p><a href="%s/reset-password?token=%s">Reset password</a></p><p>This link expires in %d minutes and works only once. If you didn't ask for it, ignore this email.</p>`,
+			config.AppConfig.FrontendURL, resetToken, int(passwordResetTTL.Minutes())),
 	}
 	_ = utils.SendEmail(emailData)
-	c.JSON(200, gin.H{"msg": "Temporary email sent to ur mail✅✨"})
-}
\ No newline at end of file
+	c.JSON(200, gin.H{"msg": "If that email exists, a reset link is sent to it✅✨"})
+}
+
+// step 2: new password in exchange for the reset token, then every old session is revoked
+func UserResetPass(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	type UserResetPass struct {
+		Token       string `json:"token" form:"token"`
+		Newpassword string `json:"newpassword" form:"newpassword"`
+	}
+	var inputUser UserResetPass
+	if err := c.ShouldBindJSON(&inputUser); err != nil {
+		c.JSON(400, gin.H{"msg": "invalid request"})
+		return
+	}
+	if inputUser.Token == "" || inputUser.Newpassword == "" {
+		c.JSON(400, gin.H{"msg": "invalid request, fill all fields"})
+		return
+	}
+	tokenHash := utils.HashToken(inputUser.Token)
+	var user models.User
+	err := userCollection.FindOne(ctx, bson.M{
+		"passwordReset.tokenHash": tokenHash,
+		"passwordReset.expiry":    bson.M{"$gt": time.Now()},
+	}).Decode(&user)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "invalid or expired reset token"})
+		return
+	}
+	middleware.SetAuditActor(c, user.ID, "user", user.Email)
+	if !checkNewPassword(c, inputUser.Newpassword, user.Password, user.PasswordHistory) {
+		return
+	}
+	fields, err := newPasswordFields(inputUser.Newpassword, user.Password, user.PasswordHistory)
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "hashing failed"})
+		return
+	}
+	fields["passwordReset.tokenHash"] = ""
+	fields["passwordReset.expiry"] = time.Time{}
+	// filter on the token hash so the link can only be used once
+	res, err := userCollection.UpdateOne(ctx, bson.M{"_id": user.ID, "passwordReset.tokenHash": tokenHash}, bson.M{
+		"$set": fields,
+	})
+	if err != nil {
+		c.JSON(400, gin.H{"msg": "invalid db error"})
+		return
+	}
+	if res.ModifiedCount == 0 {
+		c.JSON(400, gin.H{"msg": "invalid or expired reset token"})
+		return
+	}
+	if err := utils.RevokeAccountAccess(ctx, sessionCollection, user.ID, "user"); err != nil {
+		c.JSON(500, gin.H{"msg": "password reset but couldn't revoke old sessions"})
+		return
+	}
+	c.JSON(200, gin.H{"msg": "Password Reset Successfully, please login again✅"})
+}
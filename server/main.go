@@ -1,15 +1,17 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"log"
+	"os"
 	"time"
 
 	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/config"
 	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/controllers/private"
-	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/controllers/private/routes"
 	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/controllers/public"
 	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/middleware"
-	// "github.com/AbdulRahman-04/GoProjects/EventManagement/server/routes"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/routes"
 	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -19,6 +21,42 @@ import (
 )
 
 func main() {
+	// ----------------- CLI: go run . jwt-genkey <kid> -----------------
+	if len(os.Args) == 3 && os.Args[1] == "jwt-genkey" {
+		file, err := utils.GenerateJWTKey(os.Args[2])
+		if err != nil {
+			log.Fatal("❌ couldn't generate jwt key: ", err)
+		}
+		fmt.Println("✅ jwt key written to", file)
+		return
+	}
+
+	// ----------------- CLI: go run . ticket-genkey -----------------
+	if len(os.Args) == 2 && os.Args[1] == "ticket-genkey" {
+		file, err := utils.GenerateTicketKey()
+		if err != nil {
+			log.Fatal("❌ couldn't generate ticket key: ", err)
+		}
+		fmt.Println("✅ ticket key written to", file)
+		return
+	}
+
+	// ----------------- CLI: go run . bootstrap-admin -name .. -email .. -password .. -phone .. -----------------
+	if len(os.Args) > 1 && os.Args[1] == "bootstrap-admin" {
+		bootstrapAdmin(os.Args[2:])
+		return
+	}
+
+	// ----------------- JWT signing keys -----------------
+	if err := utils.LoadJWTKeys(); err != nil {
+		panic(fmt.Sprintf("❌ JWT keys loading failed: %v", err))
+	}
+
+	// ----------------- Ticket signing key (ticketing is off without it) -----------------
+	if err := utils.LoadTicketKey(); err != nil {
+		fmt.Println("⚠️", err)
+	}
+
 	// ----------------- DB + Redis -----------------
 	utils.DBConnect()
 	utils.ConnectRedis()
@@ -51,10 +89,27 @@ func main() {
 	// ----------------- Function calls -----------------
 	public.UserCollect()
 	public.AdminCollect()
+	public.SessionCollect()
+	public.SettingsCollect()
+	public.DiscoveryCollect()
 	private.UserAccessCollect()
 	private.EventsCollect()
 	private.FunctionCollect()
 	private.AdminAccessCollect()
+	private.SessionAccessCollect()
+	private.SettingsAccessCollect()
+	private.InvitationAccessCollect()
+	private.APITokenAccessCollect()
+	middleware.APITokenCollect()
+	private.RoleAccessCollect()
+	middleware.RolesCollect()
+	middleware.AuditCollect()
+	private.AuditAccessCollect()
+	middleware.ImpersonationCollect()
+	private.BookingsCollect()
+
+	// ----------------- Background jobs -----------------
+	private.StartStatusScheduler()
 
 	router.GET("/", func(c *gin.Context) {
 		c.JSON(200, gin.H{"msg": "Hello World From Gin"})
@@ -68,6 +123,27 @@ func main() {
 	router.Run(fmt.Sprintf(":%d", config.AppConfig.Port))
 }
 
+// ----------------- First super admin -----------------
+func bootstrapAdmin(args []string) {
+	fs := flag.NewFlagSet("bootstrap-admin", flag.ExitOnError)
+	var input public.BootstrapInput
+	fs.StringVar(&input.AdminName, "name", "", "admin name")
+	fs.StringVar(&input.Email, "email", "", "admin email")
+	fs.StringVar(&input.Password, "password", "", "admin password")
+	fs.StringVar(&input.Phone, "phone", "", "admin phone")
+	fs.StringVar(&input.Language, "language", "English", "Hindi, English, Urdu or Kannada")
+	fs.StringVar(&input.Location, "location", "", "admin location")
+	_ = fs.Parse(args)
+
+	utils.DBConnect()
+	public.AdminCollect()
+
+	if err := public.BootstrapSuperAdmin(input); err != nil {
+		log.Fatal("❌ couldn't create super admin: ", err)
+	}
+	fmt.Println("✅ Super admin created, login at /api/public/admins/signin")
+}
+
 // ----------------- Secure Headers Middleware -----------------
 func SecureHeaders() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var apiTokenCollection *mongo.Collection
+var apiTokenUserCollection *mongo.Collection
+
+func APITokenCollect() {
+	apiTokenCollection = utils.MongoClient.Database("Event_Booking").Collection("apiTokens")
+	apiTokenUserCollection = utils.MongoClient.Database("Event_Booking").Collection("user")
+}
+
+// authenticate a personal access token, sets the same context keys as a jwt plus the token scopes
+func apiTokenAuth(c *gin.Context, raw string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// find a live token and mark it used in one go
+	var apiToken models.APIToken
+	err := apiTokenCollection.FindOneAndUpdate(ctx, bson.M{
+		"tokenHash": utils.HashToken(raw),
+		"revoked":   false,
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}, bson.M{
+		"$set": bson.M{"lastUsedAt": time.Now()},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&apiToken)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(401, gin.H{"msg": "Invalid or Expired Token❌"})
+		c.Abort()
+		return false
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"msg": "Could not verify token, try again"})
+		c.Abort()
+		return false
+	}
+
+	// owner must still exist and not be suspended
+	var owner models.User
+	err = apiTokenUserCollection.FindOne(ctx, bson.M{"_id": apiToken.UserId}).Decode(&owner)
+	if err == mongo.ErrNoDocuments || (err == nil && owner.Suspended) {
+		c.JSON(401, gin.H{"msg": "Token has been revoked❌"})
+		c.Abort()
+		return false
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"msg": "Could not verify token, try again"})
+		c.Abort()
+		return false
+	}
+
+	c.Set("userId", apiToken.UserId)
+	c.Set("role", owner.Role)
+	c.Set("accountType", "user")
+	c.Set("sessionId", "")
+	c.Set("jti", "")
+	c.Set("tokenExp", apiToken.ExpiresAt)
+	c.Set("apiTokenId", apiToken.ID)
+	c.Set("scopes", apiToken.Scopes)
+	return true
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var auditCollection *mongo.Collection
+
+// AuditCollect sets the audit collection and its query indexes.
+// nothing in the app updates or deletes audit entries, give the app's db user insert+find only on it
+func AuditCollect() {
+	auditCollection = utils.MongoClient.Database("Event_Booking").Collection("audit")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := auditCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "time", Value: -1}}},
+		{Keys: bson.D{{Key: "action", Value: 1}, {Key: "time", Value: -1}}},
+		{Keys: bson.D{{Key: "actor.id", Value: 1}, {Key: "time", Value: -1}}},
+		{Keys: bson.D{{Key: "target.id", Value: 1}, {Key: "time", Value: -1}}},
+	}, options.CreateIndexes())
+	if err != nil {
+		fmt.Println("⚠️ couldn't create audit indexes:", err)
+	}
+}
+
+// context keys handlers use to tell Audit who acted and on what
+const (
+	auditActorIdKey    = "auditActorId"
+	auditActorTypeKey  = "auditActorType"
+	auditActorEmailKey = "auditActorEmail"
+	auditTargetTypeKey = "auditTargetType"
+	auditTargetIdKey   = "auditTargetId"
+)
+
+// SetAuditActor records who is acting on a route without AuthMiddleware (sign in, reset password...)
+func SetAuditActor(c *gin.Context, id primitive.ObjectID, accountType string, email string) {
+	if !id.IsZero() {
+		c.Set(auditActorIdKey, id.Hex())
+	}
+	c.Set(auditActorTypeKey, accountType)
+	if email != "" {
+		c.Set(auditActorEmailKey, email)
+	}
+}
+
+// SetAuditTarget records what the action was done to, when it isn't the :id route param
+func SetAuditTarget(c *gin.Context, targetType string, id string) {
+	c.Set(auditTargetTypeKey, targetType)
+	c.Set(auditTargetIdKey, id)
+}
+
+// Audit writes one audit entry for the request after the handler ran, the outcome comes from the response status.
+// targetType names what a :id route param points at, leave it empty when the route has none
+func Audit(action string, targetType ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		writeAudit(c, action, targetType)
+	}
+}
+
+// writeAudit builds the entry from the finished request and inserts it in the background
+func writeAudit(c *gin.Context, action string, targetType []string) {
+	c.Set("audited", true)
+
+	entry := models.AuditEntry{
+		ID:        primitive.NewObjectID(),
+		Time:      time.Now(),
+		Action:    action,
+		Status:    c.Writer.Status(),
+		Method:    c.Request.Method,
+		Path:      c.Request.URL.Path,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+
+	switch status := entry.Status; {
+	case status < 400:
+		entry.Outcome = "success"
+	case status == 401 || status == 403 || status == 429:
+		entry.Outcome = "denied"
+	default:
+		entry.Outcome = "failure"
+	}
+
+	// signed in => AuthMiddleware knows the actor, else the handler may have told us
+	if id, ok := c.Get("userId"); ok {
+		entry.Actor.Id = id.(primitive.ObjectID).Hex()
+		entry.Actor.Type = c.GetString("accountType")
+	} else {
+		entry.Actor.Id = c.GetString(auditActorIdKey)
+		entry.Actor.Type = c.GetString(auditActorTypeKey)
+	}
+	if entry.Actor.Type == "" {
+		entry.Actor.Type = "anonymous"
+	}
+	entry.Actor.Email = c.GetString(auditActorEmailKey)
+	entry.Actor.ImpersonatorId = c.GetString("impersonatorId")
+
+	if t := c.GetString(auditTargetTypeKey); t != "" {
+		entry.Target = models.AuditTarget{Type: t, Id: c.GetString(auditTargetIdKey)}
+	} else if len(targetType) > 0 && c.Param("id") != "" {
+		entry.Target = models.AuditTarget{Type: targetType[0], Id: c.Param("id")}
+	}
+
+	// don't make the response wait on the audit write
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if _, err := auditCollection.InsertOne(ctx, entry); err != nil {
+			fmt.Println("⚠️ couldn't write audit entry:", entry.Action, err)
+		}
+	}()
+}
@@ -2,15 +2,14 @@ package middleware
 
 import (
 	"strings"
+	"time"
 
-	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/config"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-var myKey = []byte(config.AppConfig.JWTKEY)
-
 func AuthMiddleware() gin.HandlerFunc{
 	return func(c *gin.Context) {
         
@@ -34,9 +33,16 @@ func AuthMiddleware() gin.HandlerFunc{
 
 		myToken := parts[1]
 
-		token, err := jwt.Parse(myToken, func(t *jwt.Token) (interface{}, error) {
-			return myKey, nil
-		})
+		// personal access tokens (evt_...) are looked up in db instead of parsed
+		if utils.IsAPIToken(myToken) {
+			if apiTokenAuth(c, myToken) {
+				c.Next()
+			}
+			return
+		}
+
+		// checks kid + alg against our loaded keys
+		token, err := utils.ParseToken(myToken)
 		if err != nil {
 			c.JSON(400, gin.H{
 				"msg": "Invalid or Expired Token❌",
@@ -55,6 +61,15 @@ func AuthMiddleware() gin.HandlerFunc{
 			return 
 		}
 
+		// only access tokens, never mfa pending or other special tokens
+		if claims["typ"] != "access" {
+			c.JSON(401, gin.H{
+				"msg": "Invalid or Expired Token❌",
+			})
+			c.Abort()
+			return
+		}
+
 		// get userId from token 
 		userStrId, ok := claims["id"].(string)
 		if !ok {
@@ -83,9 +98,76 @@ func AuthMiddleware() gin.HandlerFunc{
 			return 
 		}
 
-		// set the role and userid in context variable
+		// collection the account lives in, tokens from before the acct claim only had the role
+		acct, _ := claims["acct"].(string)
+		if acct == "" {
+			acct = "user"
+			if role == "admin" {
+				acct = "admin"
+			}
+		}
+
+		// session (device) the token was issued for
+		sessionId, _ := claims["sid"].(string)
+
+		// reject tokens that were logged out / revoked before they expired
+		jti, _ := claims["jti"].(string)
+		denied, err := utils.IsTokenDenied(jti, sessionId, userStrId, utils.IssuedAtMs(claims))
+		if err != nil {
+			c.JSON(500, gin.H{
+				"msg": "Could not verify token, try again",
+			})
+			c.Abort()
+			return
+		}
+		if denied {
+			c.JSON(401, gin.H{
+				"msg": "Token has been revoked❌",
+			})
+			c.Abort()
+			return
+		}
+
+		// admin acting as this user, the session must still be open
+		impersonatorId, impersonatorEmail := "", ""
+		if act, ok := claims["act"].(map[string]interface{}); ok && claims["imp"] == true {
+			impersonatorId, _ = act["sub"].(string)
+			impersonatorEmail, _ = act["email"].(string)
+
+			active, err := impersonationActive(sessionId)
+			if err != nil {
+				c.JSON(500, gin.H{
+					"msg": "Could not verify token, try again",
+				})
+				c.Abort()
+				return
+			}
+			if !active {
+				c.JSON(401, gin.H{
+					"msg": "Token has been revoked❌",
+				})
+				c.Abort()
+				return
+			}
+		}
+
+		var tokenExp time.Time
+		if exp, _ := claims.GetExpirationTime(); exp != nil {
+			tokenExp = exp.Time
+		}
+
+		// set the role, userid and session in context variable
 		c.Set("userId", userId)
 		c.Set("role", role)
+		c.Set("accountType", acct)
+		c.Set("sessionId", sessionId)
+		c.Set("jti", jti)
+		c.Set("tokenExp", tokenExp)
+		if impersonatorId != "" {
+			c.Set("impersonated", true)
+			c.Set("impersonatorId", impersonatorId)
+			c.Set("impersonatorEmail", impersonatorEmail)
+		}
 
 		c.Next()
 	}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var impersonationSessionCollection *mongo.Collection
+
+func ImpersonationCollect() {
+	impersonationSessionCollection = utils.MongoClient.Database("Event_Booking").Collection("sessions")
+}
+
+// impersonationActive checks the session behind an impersonation token on every request,
+// so the user or any admin revoking it cuts the admin off right away
+func impersonationActive(sessionId string) (bool, error) {
+	sid, err := primitive.ObjectIDFromHex(sessionId)
+	if err != nil {
+		return false, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	count, err := impersonationSessionCollection.CountDocuments(ctx, bson.M{
+		"_id":           sid,
+		"revoked":       false,
+		"impersonation": bson.M{"$exists": true},
+	})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// NoImpersonation blocks credential and security changes for admins acting as a user
+func NoImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetBool("impersonated") {
+			c.JSON(403, gin.H{
+				"msg": "Not allowed while impersonating a user⚠️",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// AuditImpersonated writes an audit entry for every request made with an impersonation token
+func AuditImpersonated() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		// routes with their own Audit already recorded the request
+		if c.GetBool("impersonated") && !c.GetBool("audited") {
+			writeAudit(c, "impersonation.request", nil)
+		}
+	}
+}
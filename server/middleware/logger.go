@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"fmt"
 	"log"
 	"time"
 
@@ -19,12 +20,19 @@ func CustomLogger() gin.HandlerFunc {
 		path := c.Request.URL.Path
 		clientIP := c.ClientIP()
 
-		log.Printf("[IVENTS LOG] %v | %3d | %-7s | %s | %s",
+		// requests made by an admin acting as a user are tagged with the admin
+		impersonation := ""
+		if c.GetBool("impersonated") {
+			impersonation = fmt.Sprintf(" | IMPERSONATED by admin %s (%s)", c.GetString("impersonatorId"), c.GetString("impersonatorEmail"))
+		}
+
+		log.Printf("[IVENTS LOG] %v | %3d | %-7s | %s | %s%s",
 			start.Format("2006-01-02 15:04:05"),
 			status,
 			method,
 			path,
 			clientIP,
+			impersonation,
 		)
 
 		log.Printf("→ Took %v\n", duration)
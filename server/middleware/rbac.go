@@ -1,34 +1,169 @@
 package middleware
 
-import "github.com/gin-gonic/gin"
-
-// only admins
-func OnlyAdmins() gin.HandlerFunc{
-	return  func(c *gin.Context) {
-		role, exists := c.Get("role")
-		if !exists || role != "admin"{
-			c.JSON(400, gin.H{
-				"msg": "Access Denied on this route⚠️, Only Admins allowed here",
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var roleCollection *mongo.Collection
+
+// role permissions are read on every request, so keep them in memory for a bit
+const roleCacheTTL = 30 * time.Second
+
+var (
+	roleCacheMu     sync.RWMutex
+	roleCache       = map[string][]string{}
+	roleCacheLoaded time.Time
+)
+
+// RolesCollect sets the roles collection and seeds the default roles
+func RolesCollect() {
+	roleCollection = utils.MongoClient.Database("Event_Booking").Collection("roles")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, role := range models.DefaultRoles {
+		// admin always holds every permission, the rest are only created when missing
+		update := bson.M{"$setOnInsert": bson.M{"permissions": role.Permissions, "builtin": true, "updated_at": time.Now()}}
+		if role.Name == "admin" {
+			update = bson.M{"$set": bson.M{"permissions": role.Permissions, "builtin": true, "updated_at": time.Now()}}
+		}
+		_, _ = roleCollection.UpdateOne(ctx, bson.M{"_id": role.Name}, update, options.Update().SetUpsert(true))
+	}
+}
+
+// InvalidateRoleCache makes the next request reload roles, call it after editing a role
+func InvalidateRoleCache() {
+	roleCacheMu.Lock()
+	roleCacheLoaded = time.Time{}
+	roleCacheMu.Unlock()
+}
+
+// RolePermissions returns the permissions of a role, an unknown role has none
+func RolePermissions(role string) ([]string, error) {
+	roleCacheMu.RLock()
+	if time.Since(roleCacheLoaded) < roleCacheTTL {
+		perms := roleCache[role]
+		roleCacheMu.RUnlock()
+		return perms, nil
+	}
+	roleCacheMu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := roleCollection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var roles []models.Role
+	if err := cursor.All(ctx, &roles); err != nil {
+		return nil, err
+	}
+
+	fresh := make(map[string][]string, len(roles))
+	for _, r := range roles {
+		fresh[r.Name] = r.Permissions
+	}
+
+	roleCacheMu.Lock()
+	roleCache = fresh
+	roleCacheLoaded = time.Now()
+	roleCacheMu.Unlock()
+
+	return fresh[role], nil
+}
+
+// RequirePermission lets the request through only if the account's role holds every listed permission,
+// api tokens only get here on routes that ran RequireScopes first
+func RequirePermission(perms ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, isAPIToken := c.Get("scopes"); isAPIToken && !c.GetBool("scopesChecked") {
+			c.JSON(403, gin.H{
+				"msg": "Api tokens can't be used on this route⚠️",
+			})
+			c.Abort()
+			return
+		}
+
+		granted, err := RolePermissions(c.GetString("role"))
+		if err != nil {
+			c.JSON(500, gin.H{
+				"msg": "Could not check permissions, try again",
+			})
+			c.Abort()
+			return
+		}
+		if !containsAll(granted, perms) {
+			c.JSON(403, gin.H{
+				"msg":                 "Access Denied on this route⚠️",
+				"requiredPermissions": perms,
 			})
 			c.Abort()
-			return 
+			return
 		}
 		c.Next()
 	}
 }
 
+// RequireScopes limits api tokens to the listed scopes, jwt sessions have every scope
+// (a route without RequireScopes can't be used with an api token at all)
+func RequireScopes(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v, isAPIToken := c.Get("scopes")
+		if isAPIToken {
+			granted, _ := v.([]string)
+			if len(scopes) == 0 || !containsAll(granted, scopes) {
+				c.JSON(403, gin.H{
+					"msg":            "Your api token is missing a required scope⚠️",
+					"requiredScopes": scopes,
+				})
+				c.Abort()
+				return
+			}
+			c.Set("scopesChecked", true)
+		}
+		c.Next()
+	}
+}
 
-// only users
-func OnlyUsers() gin.HandlerFunc{
-	return  func(c *gin.Context) {
-		role, exists := c.Get("role")
-		if !exists || role != "user"{
-			c.JSON(400, gin.H{
-				"msg": "Access Denied on this route⚠️, Only Users allowed here",
+// no api tokens, for account and security routes that need a real sign in
+func NoAPITokens() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, isAPIToken := c.Get("scopes"); isAPIToken {
+			c.JSON(403, gin.H{
+				"msg": "Api tokens can't be used on this route⚠️",
 			})
 			c.Abort()
-			return 
+			return
 		}
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+func containsAll(granted []string, required []string) bool {
+	for _, r := range required {
+		found := false
+		for _, g := range granted {
+			if g == r {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
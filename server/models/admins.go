@@ -10,25 +10,39 @@ import (
 type Admin struct {
 	ID primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	Role string `bson:"role" json:"role"`
+	// the first admin, created from the cli bootstrap
+	SuperAdmin bool `bson:"superAdmin" json:"superAdmin"`
 
 	AdminName string `bson:"adminname" json:"adminname" binding:"required"`
 	Email string `bson:"email" json:"email" binding:"required"` // You can optionally add: ,email
 	Password string `bson:"password" json:"password" binding:"required,min=6"`
+	// hashes of the previous passwords, newest first, so they can't be reused
+	PasswordHistory []string `bson:"passwordHistory,omitempty" json:"-"`
 	Phone string  `bson:"phone" json:"phone" binding:"required,min=6"` // Better to use len=10 if fixed length
 	Language string `bson:"language" json:"language" binding:"required,oneof=Hindi English Urdu Kannada"`
 	Location string `bson:"location" json:"location" binding:"required"`
 
 	AdminVerified struct {
 		Email bool `bson:"emailVerified" json:"emailVerified"`
+		Phone bool `bson:"phoneVerified" json:"phoneVerified"`
 	} `bson:"adminVerified" json:"adminVerified"`
 
 	AdminVerifyToken struct {
 		Email string  `bson:"emailVerifyToken" json:"emailVerifyToken"`
-		Phone string  `bson:"phoneVerifyToken" json:"phoneVerifyToken"`
+		EmailExpiry   time.Time `bson:"emailVerifyExpiry" json:"-"`
+		Phone string  `bson:"phoneVerifyToken" json:"-"` // sha256 of the sms otp
+		PhoneExpiry   time.Time `bson:"phoneOtpExpiry" json:"-"`
+		PhoneAttempts int       `bson:"phoneOtpAttempts" json:"-"`
 	} `bson:"adminVerifyToken" json:"adminVerifyToken"`
 
-	RefreshToken  string    `bson:"refreshToken,omitempty" json:"refreshToken,omitempty"`
-	RefreshExpiry time.Time `bson:"refreshExpiry,omitempty" json:"refreshExpiry,omitempty"`
+	// TOTP two factor auth
+	MFA MFA `bson:"mfa" json:"mfa"`
+
+	// single use password reset link, only the sha256 of the token is stored
+	PasswordReset struct {
+		Token  string    `bson:"tokenHash" json:"-"`
+		Expiry time.Time `bson:"expiry" json:"-"`
+	} `bson:"passwordReset" json:"-"`
 
 	CreatedAt time.Time  `bson:"created_at" json:"created_at"`
 	UpdatedAt time.Time  `bson:"updated_at" json:"updated_at"`
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// APIToken is a personal access token a user creates for scripts, the raw token is only shown once
+type APIToken struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserId     primitive.ObjectID `bson:"userId" json:"userId"`
+	Name       string             `bson:"name" json:"name"`
+	Scopes     []string           `bson:"scopes" json:"scopes"`
+	TokenHash  string             `bson:"tokenHash" json:"-"`
+	Prefix     string             `bson:"prefix" json:"prefix"`
+	ExpiresAt  time.Time          `bson:"expiresAt" json:"expiresAt"`
+	LastUsedAt time.Time          `bson:"lastUsedAt,omitempty" json:"lastUsedAt,omitempty"`
+	Revoked    bool               `bson:"revoked" json:"revoked"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// scopes a token can be given
+var APITokenScopes = []string{
+	"events:read",
+	"events:write",
+	"functions:read",
+	"functions:write",
+	"tickets:checkin", // enough for a door scanner, it can't edit the event
+}
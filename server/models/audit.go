@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditEntry is one security relevant action, entries are only ever inserted
+type AuditEntry struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Time      time.Time          `bson:"time" json:"time"`
+	Action    string             `bson:"action" json:"action"`   // e.g. "user.signin", "admin.user.suspend"
+	Outcome   string             `bson:"outcome" json:"outcome"` // "success" | "failure" | "denied"
+	Status    int                `bson:"status" json:"status"`   // http status of the response
+	Method    string             `bson:"method" json:"method"`
+	Path      string             `bson:"path" json:"path"`
+	Actor     AuditActor         `bson:"actor" json:"actor"`
+	Target    AuditTarget        `bson:"target,omitempty" json:"target,omitempty"`
+	IP        string             `bson:"ip" json:"ip"`
+	UserAgent string             `bson:"userAgent" json:"userAgent"`
+}
+
+// AuditActor is who did it, Id is empty when the account isn't known (e.g. sign in with an unknown email)
+type AuditActor struct {
+	Id    string `bson:"id,omitempty" json:"id,omitempty"`
+	Type  string `bson:"type" json:"type"` // "user" | "admin" | "anonymous"
+	Email string `bson:"email,omitempty" json:"email,omitempty"`
+	// admin acting through an impersonation token, Id is then the impersonated user
+	ImpersonatorId string `bson:"impersonatorId,omitempty" json:"impersonatorId,omitempty"`
+}
+
+// AuditTarget is what it was done to, when that's not the actor itself.
+// Id is the object id, or the email when that's all the request had (e.g. unlock)
+type AuditTarget struct {
+	Type string `bson:"type,omitempty" json:"type,omitempty"`
+	Id   string `bson:"id,omitempty" json:"id,omitempty"`
+}
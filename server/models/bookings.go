@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// booking states
+const (
+	BookingConfirmed  = "confirmed"
+	BookingWaitlisted = "waitlisted"
+	BookingCancelled  = "cancelled"
+)
+
+// Booking is one user's RSVP to an event. the waitlist is first come first served on CreatedAt
+type Booking struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	EventId     primitive.ObjectID `bson:"eventId" json:"eventId"`
+	UserId      primitive.ObjectID `bson:"userId" json:"userId"`
+	Status      string             `bson:"status" json:"status"`
+	Active      bool               `bson:"active" json:"-"` // confirmed or waitlisted, one active booking per user and event
+	TicketType  string             `bson:"ticketType" json:"ticketType,omitempty"`
+	TicketId    string             `bson:"ticketId,omitempty" json:"ticketId,omitempty"` // set once confirmed
+	CheckedInAt time.Time          `bson:"checkedInAt,omitempty" json:"checkedInAt,omitempty"`
+	CheckedInBy primitive.ObjectID `bson:"checkedInBy,omitempty" json:"checkedInBy,omitempty"`
+	ConfirmedAt time.Time          `bson:"confirmedAt,omitempty" json:"confirmedAt,omitempty"`
+	CancelledAt time.Time          `bson:"cancelledAt,omitempty" json:"cancelledAt,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+}
@@ -20,6 +20,29 @@ type Event struct {
 	Status string `bson:"status" json:"status" binding:"required,oneof=Upcoming Cancelled Completed"`
 	Location string `bson:"location" json:"location" binding:"required,min=15,max=100"`
 
+	// when it happens, stored in UTC. Timezone is the IANA zone it was planned in
+	StartAt  time.Time `bson:"startAt" json:"startAt"`
+	EndAt    time.Time `bson:"endAt" json:"endAt"`
+	Timezone string    `bson:"timezone" json:"timezone"`
+	// where it is on the map, optional. filled from Location by the geocoder when not given
+	Coordinates *GeoPoint `bson:"coordinates,omitempty" json:"coordinates,omitempty"`
+	// km from the ?near= point, filled on geo listings, never stored
+	DistanceKm *float64 `bson:"-" json:"distanceKm,omitempty"`
+	// set when the event repeats
+	Recurrence *Recurrence `bson:"recurrence,omitempty" json:"recurrence,omitempty"`
+	// the dates inside the requested from/to window, filled on listings, never stored
+	Occurrences []Occurrence `bson:"-" json:"occurrences,omitempty"`
+
+	// every status change, oldest first
+	StatusHistory []StatusChange `bson:"statusHistory" json:"statusHistory"`
+
+	// seats taken, never above EventAttendence. only the booking code touches it
+	ConfirmedCount int `bson:"confirmedCount" json:"confirmedCount"`
+	// users allowed to RSVP to a private event
+	InvitedUsers []primitive.ObjectID `bson:"invitedUsers,omitempty" json:"invitedUsers,omitempty"`
+	// when set, every RSVP picks one of these and gets a signed ticket
+	TicketTypes []TicketType `bson:"ticketTypes,omitempty" json:"ticketTypes,omitempty"`
+
 	CreatedAt        time.Time `bson:"created_at" json:"created_at"`
 	UpdatedAt        time.Time `bson:"updated_at" json:"updated_at"`
 }
@@ -16,6 +16,16 @@ type Function struct{
 	IsPublic string `bson:"ispublic" json:"ispublic" binding:"required,oneof=public private"`
 	Status string `bson:"status" json:"status" binding:"required,oneof=Upcoming Cancelled Completed"`
 	Location string `bson:"location" json:"location" binding:"required,min=15,max=100"`
+	// when it happens, stored in UTC. Timezone is the IANA zone it was planned in
+	StartAt time.Time `bson:"startAt" json:"startAt"`
+	EndAt time.Time `bson:"endAt" json:"endAt"`
+	Timezone string `bson:"timezone" json:"timezone"`
+	// where it is on the map, optional. filled from Location by the geocoder when not given
+	Coordinates *GeoPoint `bson:"coordinates,omitempty" json:"coordinates,omitempty"`
+	// km from the ?near= point, filled on geo listings, never stored
+	DistanceKm *float64 `bson:"-" json:"distanceKm,omitempty"`
+	// every status change, oldest first
+	StatusHistory []StatusChange `bson:"statusHistory" json:"statusHistory"`
 	CreatedAt time.Time `bson:"created_at" json:"created_at"`
 	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
 }
\ No newline at end of file
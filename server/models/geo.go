@@ -0,0 +1,12 @@
+package models
+
+// GeoPoint is a GeoJSON point, coordinates are [longitude, latitude] like mongo wants them
+type GeoPoint struct {
+	Type        string    `bson:"type" json:"type"`
+	Coordinates []float64 `bson:"coordinates" json:"coordinates"`
+}
+
+// NewGeoPoint builds a point from the usual lat, lng order
+func NewGeoPoint(lat float64, lng float64) *GeoPoint {
+	return &GeoPoint{Type: "Point", Coordinates: []float64{lng, lat}}
+}
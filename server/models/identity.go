@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// ExternalIdentity links an account to a login at an OpenID Connect provider
+type ExternalIdentity struct {
+	Issuer   string    `bson:"issuer" json:"issuer"`
+	Subject  string    `bson:"subject" json:"subject"`
+	Email    string    `bson:"email" json:"email"`
+	LinkedAt time.Time `bson:"linkedAt" json:"linkedAt"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Invitation lets exactly one person (Email) create an admin account, once, before ExpiresAt
+type Invitation struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Email     string             `bson:"email" json:"email"`
+	TokenHash string             `bson:"tokenHash" json:"-"`
+	InvitedBy primitive.ObjectID `bson:"invitedBy" json:"invitedBy"`
+	ExpiresAt time.Time          `bson:"expiresAt" json:"expiresAt"`
+	Used      bool               `bson:"used" json:"used"`
+	UsedAt    time.Time          `bson:"usedAt,omitempty" json:"usedAt,omitempty"`
+	Revoked   bool               `bson:"revoked" json:"revoked"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// MFA is the TOTP second factor of a user or admin
+type MFA struct {
+	Enabled bool   `bson:"enabled" json:"enabled"`
+	Secret  string `bson:"secret" json:"-"`
+	// set by enroll, becomes Secret once the first code is confirmed
+	PendingSecret string `bson:"pendingSecret" json:"-"`
+	// sha256 hashes of the unused recovery codes
+	RecoveryCodes []string  `bson:"recoveryCodes" json:"-"`
+	EnabledAt     time.Time `bson:"enabledAt,omitempty" json:"enabledAt,omitempty"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Recurrence makes an event repeat. the event's StartAt/EndAt are the first occurrence,
+// the others keep its length and wall clock time in the event's timezone
+type Recurrence struct {
+	RRule string `bson:"rrule" json:"rrule"` // RFC 5545 RRULE without DTSTART, e.g. FREQ=WEEKLY;BYDAY=FR;COUNT=10
+	// end of the last occurrence, range queries match series on it
+	EndAt time.Time `bson:"seriesEndAt" json:"seriesEndAt"`
+	// cancelled occurrences, by their original start
+	ExDates []time.Time `bson:"exDates,omitempty" json:"exDates,omitempty"`
+	// occurrences edited on their own
+	Overrides []OccurrenceOverride `bson:"overrides,omitempty" json:"overrides,omitempty"`
+	// the series this one was split off by a "this and following" edit
+	SplitFrom primitive.ObjectID `bson:"splitFrom,omitempty" json:"splitFrom,omitempty"`
+}
+
+// OccurrenceOverride changes one occurrence, found by its original start. empty fields keep the series' value
+type OccurrenceOverride struct {
+	OriginalStart    time.Time `bson:"originalStart" json:"originalStart"`
+	StartAt          time.Time `bson:"startAt" json:"startAt"`
+	EndAt            time.Time `bson:"endAt" json:"endAt"`
+	EventName        string    `bson:"eventname,omitempty" json:"eventname,omitempty"`
+	EventDescription string    `bson:"eventdesc,omitempty" json:"eventdesc,omitempty"`
+	Location         string    `bson:"location,omitempty" json:"location,omitempty"`
+	Coordinates      *GeoPoint `bson:"coordinates,omitempty" json:"coordinates,omitempty"`
+}
+
+// Occurrence is one date of an event as listed, a single event has one.
+// OccurrenceId is the original start in RFC3339, edits of one occurrence address it by that
+type Occurrence struct {
+	OccurrenceId     string    `json:"occurrenceId"`
+	StartAt          time.Time `json:"startAt"`
+	EndAt            time.Time `json:"endAt"`
+	EventName        string    `json:"eventname"`
+	EventDescription string    `json:"eventdesc"`
+	Location         string    `json:"location"`
+	Coordinates      *GeoPoint `json:"coordinates,omitempty"`
+	Overridden       bool      `json:"overridden,omitempty"`
+}
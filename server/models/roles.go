@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Role is a named set of permissions, accounts point at it by name (users.role / admin.role)
+type Role struct {
+	Name        string    `bson:"_id" json:"name"`
+	Permissions []string  `bson:"permissions" json:"permissions"`
+	Builtin     bool      `bson:"builtin" json:"builtin"`
+	UpdatedAt   time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// every permission a role can hold
+var Permissions = []string{
+	"events.read.own",
+	"events.write.own",
+	"events.read.any",
+	"functions.read.own",
+	"functions.write.own",
+	"functions.read.any",
+	"apitokens.manage",
+	"users.read",
+	"users.suspend",
+	"accounts.unlock",
+	"admins.invite",
+	"settings.manage",
+	"roles.manage",
+	"roles.assign",
+	"audit.read",
+	"users.impersonate",
+	"bookings.own",
+	"tickets.checkin",
+}
+
+// roles seeded on startup, only created when missing so edits survive restarts
+var DefaultRoles = []Role{
+	{
+		Name:        "user",
+		Permissions: []string{"events.read.own", "events.write.own", "functions.read.own", "functions.write.own", "apitokens.manage", "bookings.own", "tickets.checkin"},
+		Builtin:     true,
+	},
+	{
+		Name:        "admin",
+		Permissions: Permissions,
+		Builtin:     true,
+	},
+	{
+		Name:        "support",
+		Permissions: []string{"users.read", "events.read.any", "functions.read.any", "accounts.unlock", "users.impersonate"},
+		Builtin:     true,
+	},
+	{
+		Name:        "moderator",
+		Permissions: []string{"users.read", "users.suspend", "events.read.any", "functions.read.any"},
+		Builtin:     true,
+	},
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session is one signed in device, it is also the rotation family of its refresh tokens
+type Session struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	AccountId primitive.ObjectID `bson:"accountId" json:"accountId"`
+	// "user" or "admin", tells which collection AccountId points into
+	AccountType string `bson:"accountType" json:"accountType"`
+
+	// sha256 hash of the current refresh token, rotated hashes are kept to catch reuse
+	RefreshToken  string    `bson:"refreshToken" json:"-"`
+	RotatedTokens []string  `bson:"rotatedTokens" json:"-"`
+	RefreshExpiry time.Time `bson:"refreshExpiry" json:"refreshExpiry"`
+
+	UserAgent string `bson:"userAgent" json:"userAgent"`
+	IP        string `bson:"ip" json:"ip"`
+
+	Revoked   bool      `bson:"revoked" json:"revoked"`
+	RevokedAt time.Time `bson:"revokedAt,omitempty" json:"revokedAt,omitempty"`
+
+	// set when an admin opened this session to see the app as the user, it has no refresh token
+	Impersonation *Impersonation `bson:"impersonation,omitempty" json:"impersonation,omitempty"`
+
+	CreatedAt  time.Time `bson:"created_at" json:"created_at"`
+	LastUsedAt time.Time `bson:"last_used_at" json:"last_used_at"`
+}
+
+// Impersonation says which admin is acting as the session's user, and why
+type Impersonation struct {
+	AdminId    primitive.ObjectID `bson:"adminId" json:"adminId"`
+	AdminEmail string             `bson:"adminEmail" json:"adminEmail"`
+	Reason     string             `bson:"reason" json:"reason"`
+	ExpiresAt  time.Time          `bson:"expiresAt" json:"expiresAt"`
+}
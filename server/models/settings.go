@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// Settings is the single app wide settings document (_id "global")
+type Settings struct {
+	ID              string    `bson:"_id" json:"-"`
+	RequireAdminMFA bool      `bson:"requireAdminMfa" json:"requireAdminMfa"`
+	UpdatedAt       time.Time `bson:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// lifecycle of events and functions
+const (
+	StatusUpcoming  = "Upcoming"
+	StatusCancelled = "Cancelled"
+	StatusCompleted = "Completed"
+)
+
+// StatusChange is one entry of a document's status history.
+// By is empty when the scheduler made the change
+type StatusChange struct {
+	From   string             `bson:"from" json:"from"`
+	To     string             `bson:"to" json:"to"`
+	At     time.Time          `bson:"at" json:"at"`
+	By     primitive.ObjectID `bson:"by,omitempty" json:"by,omitempty"`
+	Source string             `bson:"source" json:"source"` // "user" or "scheduler"
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// ticket types an event can sell
+var TicketTypeNames = []string{"General", "VIP", "Early bird"}
+
+// TicketType is one kind of ticket with its own share of the event's capacity.
+// Remaining only moves through the booking code, next to confirmedCount
+type TicketType struct {
+	Name       string    `bson:"name" json:"name"`
+	Quota      int       `bson:"quota" json:"quota"`
+	Remaining  int       `bson:"remaining" json:"remaining"`
+	SalesEndAt time.Time `bson:"salesEndAt,omitempty" json:"salesEndAt,omitempty"` // early bird cut off
+}
@@ -13,22 +13,39 @@ type User struct {
 	Username string `bson:"username" json:"username" binding:"required"`
 	Email string `bson:"email" json:"email" binding:"required"` // Optionally add: ,email
 	Password string `bson:"password" json:"password" binding:"required,min=6"`
+	// hashes of the previous passwords, newest first, so they can't be reused
+	PasswordHistory []string `bson:"passwordHistory,omitempty" json:"-"`
 	Phone string  `bson:"phone" json:"phone" binding:"required,min=6"` // Or use len=10
 	Language string `bson:"language" json:"language" binding:"required,oneof=Hindi English Urdu Kannada"`
 	Location string `bson:"location" json:"location" binding:"required"`
 
 	Userverified struct {
 		Email bool `bson:"emailVerified" json:"emailVerified"`
+		Phone bool `bson:"phoneVerified" json:"phoneVerified"`
 	} `bson:"userverified" json:"userverified"`
 
 	Userverifytoken struct {
 		Email string  `bson:"emailVerifyToken" json:"emailVerifyToken"`
-		Phone string  `bson:"phoneVerifyToken" json:"phoneVerifyToken"`
+		EmailExpiry   time.Time `bson:"emailVerifyExpiry" json:"-"`
+		Phone string  `bson:"phoneVerifyToken" json:"-"` // sha256 of the sms otp
+		PhoneExpiry   time.Time `bson:"phoneOtpExpiry" json:"-"`
+		PhoneAttempts int       `bson:"phoneOtpAttempts" json:"-"`
 	} `bson:"userverifytoken" json:"userverifytoken"`
 
-	// -------------- ADD THESE FOR REFRESH TOKEN ----------------
-	RefreshToken  string    `bson:"refreshToken" json:"refreshToken"`
-	RefreshExpiry time.Time `bson:"refreshExpiry" json:"refreshExpiry"`
+	// set by admins, suspended users can't sign in
+	Suspended bool `bson:"suspended" json:"suspended"`
+
+	// sso logins linked to this account
+	Identities []ExternalIdentity `bson:"identities,omitempty" json:"identities,omitempty"`
+
+	// TOTP two factor auth
+	MFA MFA `bson:"mfa" json:"mfa"`
+
+	// single use password reset link, only the sha256 of the token is stored
+	PasswordReset struct {
+		Token  string    `bson:"tokenHash" json:"-"`
+		Expiry time.Time `bson:"expiry" json:"-"`
+	} `bson:"passwordReset" json:"-"`
 
 	Createdat time.Time  `bson:"created_at" json:"created_at"`
 	Updatedat time.Time  `bson:"updated_at" json:"updated_at"`
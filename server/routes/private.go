@@ -11,37 +11,102 @@ func PrivateRoutes(r*gin.Engine){
 	privateGroup := r.Group("/api/private")
     privateGroup.Use(middleware.AuthMiddleware())
 	privateGroup.Use(middleware.RateLimitMiddleware(10))
+	privateGroup.Use(middleware.AuditImpersonated())
 	 
 	{
 		// events routes 
-		privateGroup.POST("/events/create", middleware.OnlyUsers(), middleware.RateLimitMiddleware(5),private.CreateEvent)
-		privateGroup.GET("/getallevents", middleware.OnlyUsers(), middleware.RateLimitMiddleware(10),private.GetAllEvents)
-		privateGroup.GET("/getoneevent/:id", middleware.OnlyUsers(), middleware.RateLimitMiddleware(10),private.GetOneEvent)
-		privateGroup.PUT("/updateevent/:id",  middleware.OnlyUsers(), middleware.RateLimitMiddleware(5),private.EditEventApi)
-		privateGroup.DELETE("/deleteoneevent/:id",  middleware.OnlyUsers(), middleware.RateLimitMiddleware(5),private.DeleteOneEvent)
-		privateGroup.DELETE("/deleteallevents", middleware.OnlyUsers(), middleware.RateLimitMiddleware(1),private.DeleteAllEvents)
+		privateGroup.POST("/events/create", middleware.RequireScopes("events:write"), middleware.RequirePermission("events.write.own"), middleware.RateLimitMiddleware(5),private.CreateEvent)
+		privateGroup.GET("/getallevents", middleware.RequireScopes("events:read"), middleware.RequirePermission("events.read.own"), middleware.RateLimitMiddleware(10),private.GetAllEvents)
+		privateGroup.GET("/getoneevent/:id", middleware.RequireScopes("events:read"), middleware.RequirePermission("events.read.own"), middleware.RateLimitMiddleware(10),private.GetOneEvent)
+		privateGroup.PUT("/updateevent/:id",  middleware.RequireScopes("events:write"), middleware.RequirePermission("events.write.own"), middleware.RateLimitMiddleware(5),private.EditEventApi)
+		privateGroup.DELETE("/deleteoneevent/:id",  middleware.RequireScopes("events:write"), middleware.RequirePermission("events.write.own"), middleware.RateLimitMiddleware(5),private.DeleteOneEvent)
+		privateGroup.DELETE("/deleteallevents", middleware.RequireScopes("events:write"), middleware.RequirePermission("events.write.own"), middleware.RateLimitMiddleware(1),private.DeleteAllEvents)
+
+		// search my own events and functions
+		privateGroup.GET("/search", middleware.RequireScopes("events:read", "functions:read"), middleware.RequirePermission("events.read.own", "functions.read.own"), middleware.RateLimitMiddleware(30), private.SearchMine)
+
+		// RSVPs, capped by the event's attendence with a waitlist after that
+		privateGroup.POST("/events/:id/rsvp", middleware.RequirePermission("bookings.own"), middleware.RateLimitMiddleware(5), private.BookEvent)
+		privateGroup.GET("/events/:id/rsvp", middleware.RequirePermission("bookings.own"), private.GetMyBooking)
+		privateGroup.DELETE("/events/:id/rsvp", middleware.RequirePermission("bookings.own"), middleware.RateLimitMiddleware(5), private.CancelBooking)
+		privateGroup.GET("/bookings", middleware.RequirePermission("bookings.own"), private.GetMyBookings)
+		privateGroup.GET("/events/:id/bookings", middleware.RequireScopes("events:read"), middleware.RequirePermission("events.read.own"), private.GetEventBookings)
+		privateGroup.POST("/events/:id/invitees", middleware.RequireScopes("events:write"), middleware.RequirePermission("events.write.own"), private.AddEventInvitees)
+		privateGroup.DELETE("/events/:id/invitees/:userId", middleware.RequireScopes("events:write"), middleware.RequirePermission("events.write.own"), private.RemoveEventInvitee)
+
+		// tickets, signed qr codes and door check-in
+		privateGroup.PUT("/events/:id/ticket-types", middleware.RequireScopes("events:write"), middleware.RequirePermission("events.write.own"), private.SetTicketTypes)
+		privateGroup.GET("/events/:id/ticket", middleware.RequirePermission("bookings.own"), private.GetMyTicket)
+		privateGroup.GET("/events/:id/ticket/qr", middleware.RequirePermission("bookings.own"), private.GetMyTicketQR)
+		privateGroup.GET("/events/:id/ticket-key", middleware.RequireScopes("events:read"), middleware.RequirePermission("events.read.own"), private.GetTicketKey)
+		privateGroup.POST("/events/:id/checkin", middleware.RequireScopes("tickets:checkin"), middleware.RequirePermission("tickets.checkin"), middleware.RateLimitMiddleware(30), private.CheckInTicket)
+
+		// one date of a repeating event, ?scope=this|following|all
+		privateGroup.PUT("/events/:id/occurrences/:occurrenceId", middleware.RequireScopes("events:write"), middleware.RequirePermission("events.write.own"), middleware.RateLimitMiddleware(5), private.EditOccurrence)
+		privateGroup.DELETE("/events/:id/occurrences/:occurrenceId", middleware.RequireScopes("events:write"), middleware.RequirePermission("events.write.own"), middleware.RateLimitMiddleware(5), private.CancelOccurrence)
 
 		// user logout api
-       privateGroup.POST("/users/logout", middleware.OnlyUsers(), private.UserLogout)
+       privateGroup.POST("/users/logout", middleware.Audit("user.logout"), middleware.NoAPITokens(), private.UserLogout)
+
+		// phone verification, for users and admins
+		privateGroup.POST("/phone/send-otp", middleware.NoAPITokens(), middleware.RateLimitMiddleware(5), middleware.NoImpersonation(), private.SendPhoneOTP)
+		privateGroup.POST("/phone/verify", middleware.Audit("account.phone.verify"), middleware.NoAPITokens(), middleware.RateLimitMiddleware(10), middleware.NoImpersonation(), private.VerifyPhoneOTP)
+
+		// two factor auth, for users and admins
+		privateGroup.POST("/mfa/enroll", middleware.Audit("account.mfa.enroll"), middleware.NoAPITokens(), middleware.NoImpersonation(), private.EnrollMFA)
+		privateGroup.POST("/mfa/confirm", middleware.Audit("account.mfa.confirm"), middleware.NoAPITokens(), middleware.RateLimitMiddleware(10), middleware.NoImpersonation(), private.ConfirmMFA)
+		privateGroup.POST("/mfa/disable", middleware.Audit("account.mfa.disable"), middleware.NoAPITokens(), middleware.RateLimitMiddleware(5), middleware.NoImpersonation(), private.DisableMFA)
+
+		// sessions (devices) routes, for users and admins
+		privateGroup.GET("/sessions", middleware.NoAPITokens(), private.ListSessions)
+		privateGroup.DELETE("/sessions/:id", middleware.Audit("account.session.revoke", "session"), middleware.NoAPITokens(), middleware.NoImpersonation(), private.RevokeSession)
+		privateGroup.POST("/sessions/revoke-others", middleware.Audit("account.sessions.revoke_others"), middleware.NoAPITokens(), middleware.NoImpersonation(), private.RevokeOtherSessions)
+
+		// personal api tokens for scripts, managed from a normal sign in only
+		privateGroup.POST("/users/api-tokens", middleware.Audit("user.apitoken.create"), middleware.RequirePermission("apitokens.manage"), middleware.RateLimitMiddleware(5), middleware.NoImpersonation(), private.CreateAPIToken)
+		privateGroup.GET("/users/api-tokens", middleware.RequirePermission("apitokens.manage"), private.GetAPITokens)
+		privateGroup.DELETE("/users/api-tokens/:id", middleware.Audit("user.apitoken.revoke", "apitoken"), middleware.RequirePermission("apitokens.manage"), middleware.NoImpersonation(), private.RevokeAPIToken)
 
 
 
 		// function routes 
-		privateGroup.POST("/func/create", middleware.OnlyUsers(),  middleware.RateLimitMiddleware(5),private.CreateFunction)
-		privateGroup.GET("/getallfunc", middleware.OnlyUsers(), middleware.RateLimitMiddleware(10),private.GetAllFunctions)
-		privateGroup.GET("/getonefunc/:id", middleware.OnlyUsers(), middleware.RateLimitMiddleware(10),private.GetOneFunction)
-		privateGroup.PUT("/updatefunc/:id", middleware.OnlyUsers(), middleware.RateLimitMiddleware(5),private.EditFunction)
-		privateGroup.DELETE("/deleteonefunc/:id", middleware.OnlyUsers(), middleware.RateLimitMiddleware(5),private.DeleteOneFunction)
-		privateGroup.DELETE("/deleteallfuncs", middleware.OnlyUsers(), middleware.RateLimitMiddleware(2),private.DeleteAllFunctions)
+		privateGroup.POST("/func/create", middleware.RequireScopes("functions:write"), middleware.RequirePermission("functions.write.own"),  middleware.RateLimitMiddleware(5),private.CreateFunction)
+		privateGroup.GET("/getallfunc", middleware.RequireScopes("functions:read"), middleware.RequirePermission("functions.read.own"), middleware.RateLimitMiddleware(10),private.GetAllFunctions)
+		privateGroup.GET("/getonefunc/:id", middleware.RequireScopes("functions:read"), middleware.RequirePermission("functions.read.own"), middleware.RateLimitMiddleware(10),private.GetOneFunction)
+		privateGroup.PUT("/updatefunc/:id", middleware.RequireScopes("functions:write"), middleware.RequirePermission("functions.write.own"), middleware.RateLimitMiddleware(5),private.EditFunction)
+		privateGroup.DELETE("/deleteonefunc/:id", middleware.RequireScopes("functions:write"), middleware.RequirePermission("functions.write.own"), middleware.RateLimitMiddleware(5),private.DeleteOneFunction)
+		privateGroup.DELETE("/deleteallfuncs", middleware.RequireScopes("functions:write"), middleware.RequirePermission("functions.write.own"), middleware.RateLimitMiddleware(2),private.DeleteAllFunctions)
 
 		// Admins access routes
-		privateGroup.GET("/admins/getallevents", middleware.OnlyAdmins(), private.GetAllEventsAdmin)
-		privateGroup.GET("/admins/getone/:id", middleware.OnlyAdmins(), private.GetOneEventAdmin)
-		privateGroup.GET("/admins/getallusers", middleware.OnlyAdmins(), private.GetAllUsersAdmin)
-		privateGroup.GET("/admins/getoneuser/:id", middleware.OnlyAdmins(), private.GetOneUser)
-		privateGroup.GET("/admins/getallfuncs", middleware.OnlyAdmins(), private.GetAllFunctionsAdmin)
-		privateGroup.GET("/admins/getonefunc/:id", middleware.OnlyAdmins(), private.GetOneFunctionAdmin)
-        privateGroup.POST("/admins/logout", middleware.OnlyAdmins(), private.AdminLogout)
+		privateGroup.GET("/admins/getallevents", middleware.Audit("admin.events.list"), middleware.RequirePermission("events.read.any"), middleware.NoImpersonation(), private.GetAllEventsAdmin)
+		privateGroup.GET("/admins/getone/:id", middleware.Audit("admin.event.view", "event"), middleware.RequirePermission("events.read.any"), middleware.NoImpersonation(), private.GetOneEventAdmin)
+		privateGroup.GET("/admins/getallusers", middleware.Audit("admin.users.list"), middleware.RequirePermission("users.read"), middleware.NoImpersonation(), private.GetAllUsersAdmin)
+		privateGroup.GET("/admins/getoneuser/:id", middleware.Audit("admin.user.view", "user"), middleware.RequirePermission("users.read"), middleware.NoImpersonation(), private.GetOneUser)
+		privateGroup.GET("/admins/getallfuncs", middleware.Audit("admin.functions.list"), middleware.RequirePermission("functions.read.any"), middleware.NoImpersonation(), private.GetAllFunctionsAdmin)
+		privateGroup.GET("/admins/getonefunc/:id", middleware.Audit("admin.function.view", "function"), middleware.RequirePermission("functions.read.any"), middleware.NoImpersonation(), private.GetOneFunctionAdmin)
+		privateGroup.PUT("/admins/settings/require-mfa", middleware.Audit("admin.settings.require_mfa"), middleware.RequirePermission("settings.manage"), middleware.NoImpersonation(), private.SetAdminMFARequirement)
+		privateGroup.POST("/admins/invites", middleware.Audit("admin.invite.create"), middleware.RequirePermission("admins.invite"), middleware.RateLimitMiddleware(10), middleware.NoImpersonation(), private.CreateAdminInvite)
+		privateGroup.GET("/admins/invites", middleware.Audit("admin.invites.list"), middleware.RequirePermission("admins.invite"), middleware.NoImpersonation(), private.GetAdminInvites)
+		privateGroup.DELETE("/admins/invites/:id", middleware.Audit("admin.invite.revoke", "invitation"), middleware.RequirePermission("admins.invite"), middleware.NoImpersonation(), private.RevokeAdminInvite)
+		privateGroup.POST("/admins/unlock", middleware.Audit("admin.account.unlock"), middleware.RequirePermission("accounts.unlock"), middleware.NoImpersonation(), private.UnlockAccount)
+		privateGroup.POST("/admins/suspenduser/:id", middleware.Audit("admin.user.suspend", "user"), middleware.RequirePermission("users.suspend"), middleware.NoImpersonation(), private.SuspendUser)
+		privateGroup.POST("/admins/unsuspenduser/:id", middleware.Audit("admin.user.unsuspend", "user"), middleware.RequirePermission("users.suspend"), middleware.NoImpersonation(), private.UnsuspendUser)
+
+		// roles and permissions
+		privateGroup.GET("/admins/roles", middleware.Audit("admin.roles.list"), middleware.RequirePermission("roles.manage"), middleware.NoImpersonation(), private.GetRoles)
+		privateGroup.PUT("/admins/roles/:name", middleware.Audit("admin.role.save"), middleware.RequirePermission("roles.manage"), middleware.NoImpersonation(), private.SaveRole)
+		privateGroup.DELETE("/admins/roles/:name", middleware.Audit("admin.role.delete"), middleware.RequirePermission("roles.manage"), middleware.NoImpersonation(), private.DeleteRole)
+		privateGroup.POST("/admins/roles/assign", middleware.Audit("admin.role.assign"), middleware.RequirePermission("roles.assign"), middleware.NoImpersonation(), private.AssignRole)
+
+		// impersonation, support acting as a user
+		privateGroup.POST("/admins/impersonate/:id", middleware.Audit("admin.impersonation.start", "user"), middleware.RequirePermission("users.impersonate"), middleware.NoImpersonation(), private.StartImpersonation)
+		privateGroup.GET("/admins/impersonations", middleware.Audit("admin.impersonations.list"), middleware.RequirePermission("users.impersonate"), middleware.NoImpersonation(), private.GetImpersonations)
+		privateGroup.DELETE("/admins/impersonations/:id", middleware.Audit("admin.impersonation.stop", "session"), middleware.RequirePermission("users.impersonate"), middleware.NoImpersonation(), private.StopImpersonation)
+
+		// security audit log
+		privateGroup.GET("/admins/audit", middleware.Audit("admin.audit.list"), middleware.RequirePermission("audit.read"), middleware.NoImpersonation(), private.GetAuditLog)
+		privateGroup.GET("/admins/audit/export", middleware.Audit("admin.audit.export"), middleware.RequirePermission("audit.read"), middleware.NoImpersonation(), private.ExportAuditLog)
+        privateGroup.POST("/admins/logout", middleware.Audit("admin.logout"), middleware.NoAPITokens(), middleware.NoImpersonation(), private.AdminLogout)
 	}
 
 }
\ No newline at end of file
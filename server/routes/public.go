@@ -7,23 +7,50 @@ import (
 )
 
 func PublicRoutes(r*gin.Engine){
+	// token verification keys for other services
+	r.GET("/.well-known/jwks.json", public.JWKS)
+
+	// browsing public events, its own group so it isn't held to the 5/min of the auth routes
+	discoveryGroup := r.Group("/api/public")
+	discoveryGroup.GET("/events", middleware.RateLimitMiddleware(600), public.GetPublicEvents)
+	discoveryGroup.GET("/functions", middleware.RateLimitMiddleware(600), public.GetPublicFunctions)
+	discoveryGroup.GET("/search", middleware.RateLimitMiddleware(300), public.SearchPublic)
+
 	publicGroup := r.Group("/api/public")
 	publicGroup.Use(middleware.RateLimitMiddleware(5))
 
 	{
 		// users public apis's
-	publicGroup.POST("/users/signup", public.UserSignUp)
-	publicGroup.POST("/users/signin", public.UserSignIn)
-	publicGroup.GET("/user/emailverify/:token", public.EmailVerifyUser)
-	publicGroup.POST("/users/change-password", public.UserChangePass)
-	publicGroup.POST("/users/forgot-password", public.UserForgotPass)
+	publicGroup.POST("/users/signup", middleware.Audit("user.signup"), public.UserSignUp)
+	publicGroup.POST("/users/signin", middleware.Audit("user.signin"), public.UserSignIn)
+	publicGroup.POST("/users/refresh", middleware.Audit("user.refresh"), public.RefreshToken)
+	publicGroup.POST("/users/mfa/verify", middleware.Audit("user.mfa.verify"), public.UserMFAVerify)
+	publicGroup.GET("/users/oidc/login", middleware.Audit("user.oidc.login"), public.UserOIDCLogin)
+	publicGroup.GET("/users/oidc/callback", middleware.Audit("user.oidc.callback"), public.UserOIDCCallback)
+	publicGroup.GET("/user/emailverify/:token", middleware.Audit("user.email.verify"), public.EmailVerifyUser)
+	publicGroup.POST("/users/resend-verification", middleware.Audit("user.email.resend"), middleware.RateLimitMiddleware(3), public.UserResendVerification)
+	publicGroup.POST("/users/change-password", middleware.Audit("user.password.change"), public.UserChangePass)
+	publicGroup.POST("/users/forgot-password", middleware.Audit("user.password.forgot"), public.UserForgotPass)
+	publicGroup.POST("/users/reset-password", middleware.Audit("user.password.reset"), public.UserResetPass)
+	publicGroup.POST("/users/magic-link", middleware.Audit("user.magiclink.request"), middleware.RateLimitMiddleware(3), public.UserMagicLink)
+	publicGroup.POST("/users/magic-link/verify", middleware.Audit("user.magiclink.login"), public.UserMagicLinkVerify)
+	publicGroup.POST("/users/phone-login", middleware.Audit("user.phonelogin.request"), middleware.RateLimitMiddleware(3), public.UserPhoneLogin)
+	publicGroup.POST("/users/phone-login/verify", middleware.Audit("user.phonelogin.login"), public.UserPhoneLoginVerify)
 
 	// admins
-	publicGroup.POST("/admins/signup", public.AdminSignUp)
-	publicGroup.POST("/admins/signin", public.AdminSignIn)
-	publicGroup.GET("/admin/emailverify/:token", public.EmailVerifyAdmin)
-	publicGroup.POST("/admins/change-password", public.AdminChangePass)
-	publicGroup.POST("/admins/forgot-password", public.AdminForgotPass)
+	publicGroup.POST("/admins/signup", middleware.Audit("admin.signup"), public.AdminSignUp)
+	publicGroup.POST("/admins/signin", middleware.Audit("admin.signin"), public.AdminSignIn)
+	publicGroup.POST("/admins/refresh", middleware.Audit("admin.refresh"), public.AdminRefreshToken)
+	publicGroup.POST("/admins/mfa/setup", middleware.Audit("admin.mfa.setup"), public.AdminMFASetup)
+	publicGroup.POST("/admins/mfa/verify", middleware.Audit("admin.mfa.verify"), public.AdminMFAVerify)
+	publicGroup.GET("/admin/emailverify/:token", middleware.Audit("admin.email.verify"), public.EmailVerifyAdmin)
+	publicGroup.POST("/admins/resend-verification", middleware.Audit("admin.email.resend"), middleware.RateLimitMiddleware(3), public.AdminResendVerification)
+	publicGroup.POST("/admins/change-password", middleware.Audit("admin.password.change"), public.AdminChangePass)
+	publicGroup.POST("/admins/forgot-password", middleware.Audit("admin.password.forgot"), public.AdminForgotPass)
+	publicGroup.POST("/admins/reset-password", middleware.Audit("admin.password.reset"), public.AdminResetPass)
+
+	// local testing only, 404 unless the fake sms sink is on
+	publicGroup.GET("/dev/sms-outbox", public.FakeSMSOutbox)
 
 	
 	}
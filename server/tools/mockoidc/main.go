@@ -0,0 +1,195 @@
+// mockoidc is a tiny OpenID Connect provider for local testing of the sso login.
+// it logs everyone in without asking, as -email (or the login_hint of the request).
+//
+//	go run ./tools/mockoidc -addr :9999 -email organiser@example.com
+//
+// then set config.AppConfig.OIDC.Enabled = true and open /api/public/users/oidc/login
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type authCode struct {
+	Email       string
+	Nonce       string
+	Challenge   string
+	RedirectURI string
+	ClientID    string
+	ExpiresAt   time.Time
+}
+
+var (
+	addr     = flag.String("addr", ":9999", "listen address")
+	issuer   = flag.String("issuer", "http://localhost:9999", "issuer url, must match config.AppConfig.OIDC.Issuer")
+	clientID = flag.String("client", "ivents", "accepted client_id")
+	email    = flag.String("email", "organiser@example.com", "email logged in when no login_hint is given")
+	verified = flag.Bool("verified", true, "email_verified claim")
+
+	key   *rsa.PrivateKey
+	codes = map[string]authCode{}
+	mu    sync.Mutex
+)
+
+const kid = "mock-1"
+
+func main() {
+	flag.Parse()
+
+	var err error
+	key, err = rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	http.HandleFunc("/.well-known/openid-configuration", discovery)
+	http.HandleFunc("/authorize", authorize)
+	http.HandleFunc("/token", token)
+	http.HandleFunc("/jwks", jwks)
+
+	fmt.Printf("✅ mock oidc provider on %s (issuer %s)\n", *addr, *issuer)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
+
+func discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, 200, map[string]interface{}{
+		"issuer":                                *issuer,
+		"authorization_endpoint":                *issuer + "/authorize",
+		"token_endpoint":                        *issuer + "/token",
+		"jwks_uri":                              *issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != *clientID || q.Get("response_type") != "code" {
+		http.Error(w, "bad client_id or response_type", 400)
+		return
+	}
+	if q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256" {
+		http.Error(w, "PKCE S256 required", 400)
+		return
+	}
+
+	who := *email
+	if hint := q.Get("login_hint"); hint != "" {
+		who = hint
+	}
+
+	code := randomHex(16)
+	mu.Lock()
+	codes[code] = authCode{
+		Email:       who,
+		Nonce:       q.Get("nonce"),
+		Challenge:   q.Get("code_challenge"),
+		RedirectURI: q.Get("redirect_uri"),
+		ClientID:    q.Get("client_id"),
+		ExpiresAt:   time.Now().Add(time.Minute),
+	}
+	mu.Unlock()
+
+	back, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "bad redirect_uri", 400)
+		return
+	}
+	bq := back.Query()
+	bq.Set("code", code)
+	bq.Set("state", q.Get("state"))
+	back.RawQuery = bq.Encode()
+	http.Redirect(w, r, back.String(), http.StatusFound)
+}
+
+func token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.Form.Get("grant_type") != "authorization_code" {
+		writeJSON(w, 400, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	mu.Lock()
+	ac, ok := codes[r.Form.Get("code")]
+	delete(codes, r.Form.Get("code")) // codes are single use
+	mu.Unlock()
+
+	if !ok || ac.ExpiresAt.Before(time.Now()) || ac.RedirectURI != r.Form.Get("redirect_uri") || ac.ClientID != r.Form.Get("client_id") {
+		writeJSON(w, 400, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != ac.Challenge {
+		writeJSON(w, 400, map[string]string{"error": "invalid_grant", "error_description": "pkce mismatch"})
+		return
+	}
+
+	subject := sha256.Sum256([]byte(strings.ToLower(ac.Email)))
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            *issuer,
+		"sub":            hex.EncodeToString(subject[:8]),
+		"aud":            ac.ClientID,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(5 * time.Minute).Unix(),
+		"nonce":          ac.Nonce,
+		"email":          ac.Email,
+		"email_verified": *verified,
+		"name":           strings.Split(ac.Email, "@")[0],
+	})
+	idToken.Header["kid"] = kid
+	signed, err := idToken.SignedString(key)
+	if err != nil {
+		writeJSON(w, 500, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, 200, map[string]interface{}{
+		"access_token": randomHex(16),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     signed,
+	})
+}
+
+func jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, 200, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kid": kid,
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func randomHex(n int) string {
+	d := make([]byte, n)
+	_, _ = rand.Read(d)
+	return hex.EncodeToString(d)
+}
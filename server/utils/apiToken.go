@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// personal access tokens start with this so AuthMiddleware can tell them apart from jwts
+const APITokenPrefix = "evt_"
+
+// GenerateAPIToken returns a new raw personal access token, only its hash is stored
+func GenerateAPIToken() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return APITokenPrefix + hex.EncodeToString(b)
+}
+
+// IsAPIToken reports whether a bearer value looks like a personal access token
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
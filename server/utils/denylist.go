@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NewTokenId returns a random id used as the jti claim of access tokens
+func NewTokenId() string {
+	d := make([]byte, 16)
+	_, _ = rand.Read(d)
+	return hex.EncodeToString(d)
+}
+
+// DenyToken puts one access token on the denylist until it would have expired anyway
+func DenyToken(jti string, exp time.Time) error {
+	ttl := time.Until(exp)
+	if jti == "" || ttl <= 0 {
+		return nil
+	}
+	return RedisClient.Set(Ctx, "denylist:jti:"+jti, "1", ttl).Err()
+}
+
+// DenySession rejects every access token of a revoked session (device)
+func DenySession(sid string) error {
+	if sid == "" {
+		return nil
+	}
+	return RedisClient.Set(Ctx, "denylist:sid:"+sid, "1", AccessTokenTTL).Err()
+}
+
+// DenyAccount rejects every access token of the account issued before now (unix ms),
+// used on password change, deletion and suspension. tokens signed right after still work
+func DenyAccount(accountId string) error {
+	return RedisClient.Set(Ctx, "denylist:account:"+accountId, strconv.FormatInt(time.Now().UnixMilli(), 10), AccessTokenTTL).Err()
+}
+
+// IsTokenDenied checks the single token, its session and the account wide denylist,
+// issuedAt is in unix ms
+func IsTokenDenied(jti string, sid string, accountId string, issuedAt int64) (bool, error) {
+	keys := []string{}
+	if jti != "" {
+		keys = append(keys, "denylist:jti:"+jti)
+	}
+	if sid != "" {
+		keys = append(keys, "denylist:sid:"+sid)
+	}
+	if len(keys) > 0 {
+		n, err := RedisClient.Exists(Ctx, keys...).Result()
+		if err != nil {
+			return false, err
+		}
+		if n > 0 {
+			return true, nil
+		}
+	}
+
+	deniedAt, err := RedisClient.Get(Ctx, "denylist:account:"+accountId).Int64()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return issuedAt < deniedAt, nil
+}
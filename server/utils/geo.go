@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// radius limits for ?near= queries, in km
+const (
+	DefaultRadiusKm = 10.0
+	MaxRadiusKm     = 500.0
+	earthRadiusKm   = 6378.1
+)
+
+// GeoQuery is a parsed ?near=lat,lng&radius=km or ?bbox=minLng,minLat,maxLng,maxLat.
+// results are sorted by distance from the point (or the middle of the box)
+type GeoQuery struct {
+	Lat      float64
+	Lng      float64
+	RadiusKm float64     // 0 for bbox queries
+	Box      *[4]float64 // minLng, minLat, maxLng, maxLat
+}
+
+// ParseGeoQuery returns nil, nil when neither near nor bbox is given
+func ParseGeoQuery(near string, radius string, bbox string) (*GeoQuery, error) {
+	if near == "" && bbox == "" {
+		return nil, nil
+	}
+	if near != "" && bbox != "" {
+		return nil, errors.New("use either near or bbox, not both")
+	}
+
+	if near != "" {
+		vals, err := parseFloats(near, 2)
+		if err != nil || !ValidLatLng(vals[0], vals[1]) {
+			return nil, errors.New("near must be lat,lng")
+		}
+		q := &GeoQuery{Lat: vals[0], Lng: vals[1], RadiusKm: DefaultRadiusKm}
+		if radius != "" {
+			r, err := strconv.ParseFloat(radius, 64)
+			if err != nil || r <= 0 || r > MaxRadiusKm {
+				return nil, fmt.Errorf("radius must be between 0 and %.0f km", MaxRadiusKm)
+			}
+			q.RadiusKm = r
+		}
+		return q, nil
+	}
+
+	vals, err := parseFloats(bbox, 4)
+	if err != nil || !ValidLatLng(vals[1], vals[0]) || !ValidLatLng(vals[3], vals[2]) || vals[0] >= vals[2] || vals[1] >= vals[3] {
+		return nil, errors.New("bbox must be minLng,minLat,maxLng,maxLat")
+	}
+	box := [4]float64{vals[0], vals[1], vals[2], vals[3]}
+	return &GeoQuery{Lat: (box[1] + box[3]) / 2, Lng: (box[0] + box[2]) / 2, Box: &box}, nil
+}
+
+// within is the $geoWithin condition on coordinates
+func (q *GeoQuery) within() bson.M {
+	if q.Box != nil {
+		b := q.Box
+		return bson.M{"$geoWithin": bson.M{"$geometry": bson.M{
+			"type": "Polygon",
+			"coordinates": bson.A{bson.A{
+				bson.A{b[0], b[1]}, bson.A{b[2], b[1]}, bson.A{b[2], b[3]}, bson.A{b[0], b[3]}, bson.A{b[0], b[1]},
+			}},
+		}}}
+	}
+	return bson.M{"$geoWithin": bson.M{"$centerSphere": bson.A{bson.A{q.Lng, q.Lat}, q.RadiusKm / earthRadiusKm}}}
+}
+
+// CountFilter is filter narrowed to the area, for counting ($geoNear can't count)
+func (q *GeoQuery) CountFilter(filter bson.M) bson.M {
+	out := bson.M{}
+	for k, v := range filter {
+		out[k] = v
+	}
+	out["coordinates"] = q.within()
+	return out
+}
+
+// Pipeline finds the page of filter's documents in the area, nearest first,
+// with the distance in km in a "distance" field
+func (q *GeoQuery) Pipeline(filter bson.M, skip int64, limit int64) mongo.Pipeline {
+	geoNear := bson.M{
+		"near":               bson.M{"type": "Point", "coordinates": bson.A{q.Lng, q.Lat}},
+		"key":                "coordinates",
+		"distanceField":      "distance",
+		"distanceMultiplier": 0.001,
+		"spherical":          true,
+		"query":              filter,
+	}
+	if q.Box != nil {
+		geoNear["query"] = q.CountFilter(filter)
+	} else {
+		geoNear["maxDistance"] = q.RadiusKm * 1000
+	}
+
+	return mongo.Pipeline{
+		{{Key: "$geoNear", Value: geoNear}},
+		{{Key: "$skip", Value: skip}},
+		{{Key: "$limit", Value: limit}},
+	}
+}
+
+// CacheKey identifies the area in cache keys
+func (q *GeoQuery) CacheKey() string {
+	if q.Box != nil {
+		return fmt.Sprintf("bbox:%g,%g,%g,%g", q.Box[0], q.Box[1], q.Box[2], q.Box[3])
+	}
+	return fmt.Sprintf("near:%g,%g,%g", q.Lat, q.Lng, q.RadiusKm)
+}
+
+func parseFloats(s string, n int) ([]float64, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != n {
+		return nil, fmt.Errorf("want %d numbers", n)
+	}
+	vals := make([]float64, n)
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, err
+		}
+		vals[i] = v
+	}
+	return vals, nil
+}
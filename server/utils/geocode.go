@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/config"
+)
+
+var ErrNotGeocoded = errors.New("couldn't find that location")
+
+// Geocoder turns a free text address into coordinates. the gazetteer works offline,
+// an online provider only has to implement this to replace it
+type Geocoder interface {
+	Geocode(ctx context.Context, address string) (lat float64, lng float64, err error)
+}
+
+var (
+	geocoderOnce sync.Once
+	geocoder     Geocoder
+)
+
+// SetGeocoder swaps the geocoder, call it at startup before serving
+func SetGeocoder(g Geocoder) {
+	geocoderOnce.Do(func() {})
+	geocoder = g
+}
+
+// Geocode uses the configured geocoder, it's loaded on first use
+func Geocode(ctx context.Context, address string) (float64, float64, error) {
+	geocoderOnce.Do(loadGeocoder)
+	if geocoder == nil {
+		return 0, 0, ErrNotGeocoded
+	}
+	return geocoder.Geocode(ctx, address)
+}
+
+func loadGeocoder() {
+	cfg := config.AppConfig.Geo
+	if cfg.Geocoder != "gazetteer" {
+		return
+	}
+	g, err := LoadGazetteer(cfg.GazetteerFile)
+	if err != nil {
+		fmt.Println("⚠️ gazetteer not loaded, no geocoding:", err)
+		return
+	}
+	geocoder = g
+}
+
+type gazetteerPlace struct {
+	Name     string // normalized
+	Lat      float64
+	Lng      float64
+	Locality bool
+}
+
+// Gazetteer is an offline geocoder over a list of known places. an address resolves to
+// the most specific place it names: localities beat cities, longer names beat shorter ones
+type Gazetteer struct {
+	places []gazetteerPlace
+}
+
+// LoadGazetteer reads name,lat,lng,kind rows (with a header line)
+func LoadGazetteer(file string) (*Gazetteer, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = 4
+	if _, err := r.Read(); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	g := &Gazetteer{}
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		lat, errLat := strconv.ParseFloat(strings.TrimSpace(row[1]), 64)
+		lng, errLng := strconv.ParseFloat(strings.TrimSpace(row[2]), 64)
+		if errLat != nil || errLng != nil || !ValidLatLng(lat, lng) {
+			return nil, fmt.Errorf("%s: bad coordinates for %q", file, row[0])
+		}
+		name := normalizePlace(row[0])
+		if name == "" {
+			continue
+		}
+		g.places = append(g.places, gazetteerPlace{
+			Name:     name,
+			Lat:      lat,
+			Lng:      lng,
+			Locality: strings.TrimSpace(row[3]) == "locality",
+		})
+	}
+	return g, nil
+}
+
+func (g *Gazetteer) Geocode(ctx context.Context, address string) (float64, float64, error) {
+	// pad with spaces so names only match whole words
+	text := " " + normalizePlace(address) + " "
+
+	var best *gazetteerPlace
+	for i := range g.places {
+		p := &g.places[i]
+		if !strings.Contains(text, " "+p.Name+" ") {
+			continue
+		}
+		if best == nil || (p.Locality && !best.Locality) || (p.Locality == best.Locality && len(p.Name) > len(best.Name)) {
+			best = p
+		}
+	}
+	if best == nil {
+		return 0, 0, ErrNotGeocoded
+	}
+	return best.Lat, best.Lng, nil
+}
+
+// normalizePlace lowercases and keeps letters and digits, everything else is one space
+func normalizePlace(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// ValidLatLng reports whether lat, lng are on the globe
+func ValidLatLng(lat float64, lng float64) bool {
+	return lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180
+}
@@ -0,0 +1,188 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwtKey is one signing key, Private is nil for verify-only keys
+type jwtKey struct {
+	Kid     string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+var jwtKeys = map[string]*jwtKey{}
+
+// LoadJWTKeys reads every <kid>.pem from the keys dir, PKCS8 private keys
+// (RSA or Ed25519) or PKIX public keys for old keys that only verify
+func LoadJWTKeys() error {
+	cfg := config.AppConfig.JWT
+
+	files, err := filepath.Glob(filepath.Join(cfg.KeysDir, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	keys := map[string]*jwtKey{}
+	for _, file := range files {
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		if slices.Contains(cfg.RetiredKids, kid) {
+			continue
+		}
+
+		key, err := readJWTKey(kid, file)
+		if err != nil {
+			return fmt.Errorf("jwt key %s: %w", kid, err)
+		}
+		keys[kid] = key
+	}
+
+	active, ok := keys[cfg.ActiveKid]
+	if !ok || active.Private == nil {
+		return fmt.Errorf("active jwt key %q not found in %s (run: go run . jwt-genkey %s)", cfg.ActiveKid, cfg.KeysDir, cfg.ActiveKid)
+	}
+
+	jwtKeys = keys
+	fmt.Printf("✅ Loaded %d jwt keys, signing with %s\n", len(keys), cfg.ActiveKid)
+	return nil
+}
+
+func readJWTKey(kid string, file string) (*jwtKey, error) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("no pem block found")
+	}
+
+	key := &jwtKey{Kid: kid}
+	switch block.Type {
+	case "PRIVATE KEY":
+		priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := priv.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("unsupported private key")
+		}
+		key.Private = signer
+		key.Public = signer.Public()
+	case "PUBLIC KEY":
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.Public = pub
+	default:
+		return nil, fmt.Errorf("unsupported pem block %q", block.Type)
+	}
+
+	switch key.Public.(type) {
+	case *rsa.PublicKey:
+		key.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, errors.New("only RSA and Ed25519 keys are supported")
+	}
+	return key, nil
+}
+
+// SignToken signs claims with the active key and puts its kid in the header
+func SignToken(claims jwt.MapClaims) (string, error) {
+	key, ok := jwtKeys[config.AppConfig.JWT.ActiveKid]
+	if !ok || key.Private == nil {
+		return "", errors.New("no active jwt key loaded")
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.Kid
+	return token.SignedString(key.Private)
+}
+
+// ParseToken verifies a token against the key named by its kid,
+// the alg must match that key's type so a token can't pick its own algorithm
+func ParseToken(tokenStr string) (*jwt.Token, error) {
+	return jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := jwtKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown kid %q", kid)
+		}
+		if t.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected alg %q for kid %q", t.Method.Alg(), kid)
+		}
+		return key.Public, nil
+	}, jwt.WithValidMethods([]string{"RS256", "EdDSA"}))
+}
+
+// JWKS returns the public half of every loaded key in RFC 7517 format
+func JWKS() map[string]interface{} {
+	keys := []map[string]string{}
+	for _, key := range jwtKeys {
+		jwk := map[string]string{
+			"kid": key.Kid,
+			"alg": key.Method.Alg(),
+			"use": "sig",
+		}
+		switch pub := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk["kty"] = "RSA"
+			jwk["n"] = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk["e"] = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk["kty"] = "OKP"
+			jwk["crv"] = "Ed25519"
+			jwk["x"] = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		keys = append(keys, jwk)
+	}
+	return map[string]interface{}{"keys": keys}
+}
+
+// GenerateJWTKey writes a new Ed25519 private key as <kid>.pem into the keys dir
+func GenerateJWTKey(kid string) (string, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return "", err
+	}
+
+	dir := config.AppConfig.JWT.KeysDir
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	file := filepath.Join(dir, kid+".pem")
+	if _, err := os.Stat(file); err == nil {
+		return "", fmt.Errorf("%s already exists", file)
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	return file, os.WriteFile(file, data, 0600)
+}
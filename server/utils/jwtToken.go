@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// access token lifetime
+const AccessTokenTTL = 5 * time.Hour
+
+// impersonation token lifetime, kept short and never refreshed
+const ImpersonationTTL = 30 * time.Minute
+
+// mfa pending token lifetime, time to type the code from the app
+const MFATokenTTL = 5 * time.Minute
+
+// GenerateAccessToken signs the short lived access token used by AuthMiddleware,
+// acct is the collection the account lives in ("user" | "admin"), role decides its permissions,
+// sid is the session (device) the token belongs to
+func GenerateAccessToken(id string, accountType string, role string, email string, sid string) (string, error) {
+	return SignToken(jwt.MapClaims{
+		"typ":   "access",
+		"id":    id,
+		"acct":  accountType,
+		"role":  role,
+		"email": email,
+		"sid":   sid,
+		"jti":   NewTokenId(),
+		"iat":   time.Now().Unix(),
+		"iatms": time.Now().UnixMilli(),
+		"exp":   time.Now().Add(AccessTokenTTL).Unix(),
+	})
+}
+
+// GenerateImpersonationToken signs an access token for a user that an admin acts through.
+// "act" names the admin (RFC 8693 actor claim) and "imp" marks it so it can't change credentials
+func GenerateImpersonationToken(id string, role string, email string, sid string, adminId string, adminEmail string) (string, time.Time, error) {
+	exp := time.Now().Add(ImpersonationTTL)
+	token, err := SignToken(jwt.MapClaims{
+		"typ":   "access",
+		"id":    id,
+		"acct":  "user",
+		"role":  role,
+		"email": email,
+		"sid":   sid,
+		"jti":   NewTokenId(),
+		"imp":   true,
+		"act": map[string]interface{}{
+			"sub":   adminId,
+			"acct":  "admin",
+			"email": adminEmail,
+		},
+		"iat":   time.Now().Unix(),
+		"iatms": time.Now().UnixMilli(),
+		"exp":   exp.Unix(),
+	})
+	return token, exp, err
+}
+
+// IssuedAtMs is when a token was signed in unix ms, iat only has seconds and
+// the account denylist has to tell apart tokens signed within one second
+func IssuedAtMs(claims jwt.MapClaims) int64 {
+	ms, _ := claims["iatms"].(float64)
+	return int64(ms)
+}
+
+// MFAClaims is what an "mfa pending" token carries between the password and the code step
+type MFAClaims struct {
+	Id          string
+	AccountType string
+	Enroll      bool // account must enroll before it gets tokens
+	Jti         string
+	IssuedAt    int64 // unix ms
+	Exp         time.Time
+}
+
+// GenerateMFAToken signs the "mfa pending" token given out after a correct password,
+// it can't be used as an access token
+func GenerateMFAToken(id string, accountType string, enroll bool) (string, error) {
+	return SignToken(jwt.MapClaims{
+		"typ":    "mfa_pending",
+		"id":     id,
+		"acct":   accountType,
+		"enroll": enroll,
+		"jti":    NewTokenId(),
+		"iat":    time.Now().Unix(),
+		"iatms":  time.Now().UnixMilli(),
+		"exp":    time.Now().Add(MFATokenTTL).Unix(),
+	})
+}
+
+// ParseMFAToken verifies an "mfa pending" token
+func ParseMFAToken(tokenStr string) (*MFAClaims, error) {
+	token, err := ParseToken(tokenStr)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["typ"] != "mfa_pending" {
+		return nil, errors.New("not an mfa token")
+	}
+
+	out := &MFAClaims{}
+	out.Id, _ = claims["id"].(string)
+	out.AccountType, _ = claims["acct"].(string)
+	out.Enroll, _ = claims["enroll"].(bool)
+	out.Jti, _ = claims["jti"].(string)
+	out.IssuedAt = IssuedAtMs(claims)
+	if exp, _ := claims.GetExpirationTime(); exp != nil {
+		out.Exp = exp.Time
+	}
+	if out.Id == "" || out.Jti == "" {
+		return nil, errors.New("incomplete mfa token")
+	}
+	return out, nil
+}
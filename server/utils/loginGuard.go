@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// brute force protection for sign in, counters live in redis so every instance shares them
+const (
+	loginFailWindow    = 15 * time.Minute
+	loginBackoffAfter  = 3  // failures before we start making people wait
+	loginLockAfter     = 10 // failures before the account is locked
+	loginLockDuration  = 15 * time.Minute
+	loginMaxBackoff    = 5 * time.Minute
+	loginIPMaxFailures = 50 // failures per ip in the window, across all accounts
+)
+
+// LoginAccountKey is the guard key of an account, built from the typed email so unknown emails are guarded too
+func LoginAccountKey(accountType string, email string) string {
+	return accountType + ":" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// MFAGuardKey is the guard key of an account's second factor. it is separate from the password key
+// because a correct password resets that one, wrong codes must keep counting across new mfa tokens
+func MFAGuardKey(accountType string, accountId string) string {
+	return "mfa:" + accountType + ":" + accountId
+}
+
+// CheckLogin tells how long the caller must wait before trying this account from this ip again,
+// zero means go ahead. locked is true when the account itself is locked
+func CheckLogin(accountKey string, ip string) (time.Duration, bool, error) {
+	ttl, err := RedisClient.TTL(Ctx, "login:lock:"+accountKey).Result()
+	if err != nil {
+		return 0, false, err
+	}
+	if ttl > 0 {
+		return ttl, true, nil
+	}
+
+	ttl, err = RedisClient.TTL(Ctx, "login:wait:"+accountKey).Result()
+	if err != nil {
+		return 0, false, err
+	}
+	if ttl > 0 {
+		return ttl, false, nil
+	}
+
+	ipFails, err := RedisClient.Get(Ctx, "login:fail:ip:"+ip).Int()
+	if err == nil && ipFails >= loginIPMaxFailures {
+		ttl, _ = RedisClient.TTL(Ctx, "login:fail:ip:"+ip).Result()
+		return ttl, false, nil
+	}
+
+	return 0, false, nil
+}
+
+// RecordLoginFailure counts a failed sign in, sets the exponential backoff and locks
+// the account once it had too many. lockedNow is true only for the failure that locked it
+func RecordLoginFailure(accountKey string, ip string) (bool, error) {
+	ipKey := "login:fail:ip:" + ip
+	if n, err := RedisClient.Incr(Ctx, ipKey).Result(); err == nil && n == 1 {
+		RedisClient.Expire(Ctx, ipKey, loginFailWindow)
+	}
+
+	failKey := "login:fail:acct:" + accountKey
+	n, err := RedisClient.Incr(Ctx, failKey).Result()
+	if err != nil {
+		return false, err
+	}
+	if n == 1 {
+		RedisClient.Expire(Ctx, failKey, loginFailWindow)
+	}
+
+	if n >= loginLockAfter {
+		RedisClient.Del(Ctx, failKey, "login:wait:"+accountKey)
+		return true, RedisClient.Set(Ctx, "login:lock:"+accountKey, "1", loginLockDuration).Err()
+	}
+
+	if n >= loginBackoffAfter {
+		wait := time.Duration(1<<(n-loginBackoffAfter)) * time.Second
+		if wait > loginMaxBackoff {
+			wait = loginMaxBackoff
+		}
+		return false, RedisClient.Set(Ctx, "login:wait:"+accountKey, "1", wait).Err()
+	}
+
+	return false, nil
+}
+
+// ResetLoginFailures clears the counters after a successful sign in
+func ResetLoginFailures(accountKey string) {
+	RedisClient.Del(Ctx, "login:fail:acct:"+accountKey, "login:wait:"+accountKey)
+}
+
+// UnlockAccount lifts a lock before it runs out, for admins
+func UnlockAccount(accountKey string) error {
+	return RedisClient.Del(Ctx, "login:lock:"+accountKey, "login:fail:acct:"+accountKey, "login:wait:"+accountKey).Err()
+}
+
+// LoginRetryMessage is the message shown when sign in is throttled
+func LoginRetryMessage(wait time.Duration, locked bool) string {
+	secs := int(wait.Seconds()) + 1
+	if locked {
+		return fmt.Sprintf("Too many failed attempts, this account is locked. Try again in %d minutes⚠️", secs/60+1)
+	}
+	return fmt.Sprintf("Too many failed attempts, try again in %d seconds⚠️", secs)
+}
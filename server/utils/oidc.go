@@ -0,0 +1,271 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OIDCProvider is the part of the discovery document we use
+type OIDCProvider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCIdentity is the verified person behind an id_token
+type OIDCIdentity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+var (
+	oidcMu        sync.Mutex
+	oidcProvider  *OIDCProvider
+	oidcKeys      map[string]interface{}
+	oidcFetchedAt time.Time
+	oidcHTTP      = &http.Client{Timeout: 10 * time.Second}
+)
+
+// discovery + keys are cached, refetched after this or on an unknown kid
+const oidcCacheTTL = time.Hour
+
+// OIDCDiscover loads (and caches) the issuer's discovery document
+func OIDCDiscover() (*OIDCProvider, error) {
+	oidcMu.Lock()
+	defer oidcMu.Unlock()
+
+	if oidcProvider != nil && time.Since(oidcFetchedAt) < oidcCacheTTL {
+		return oidcProvider, nil
+	}
+
+	issuer := strings.TrimSuffix(config.AppConfig.OIDC.Issuer, "/")
+	var provider OIDCProvider
+	if err := oidcGetJSON(issuer+"/.well-known/openid-configuration", &provider); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(provider.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("discovery issuer %q doesn't match %q", provider.Issuer, issuer)
+	}
+
+	oidcProvider = &provider
+	oidcKeys = nil
+	oidcFetchedAt = time.Now()
+	return oidcProvider, nil
+}
+
+// NewPKCE returns a random code verifier and its S256 challenge
+func NewPKCE() (string, string) {
+	verifier := NewTokenId() + NewTokenId()
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// OIDCAuthURL is where the browser goes to log in at the provider
+func OIDCAuthURL(p *OIDCProvider, state string, nonce string, challenge string) string {
+	cfg := config.AppConfig.OIDC
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", cfg.ClientID)
+	q.Set("redirect_uri", cfg.RedirectURL)
+	q.Set("scope", strings.Join(cfg.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", challenge)
+	q.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(p.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return p.AuthorizationEndpoint + sep + q.Encode()
+}
+
+// OIDCExchangeCode trades the authorization code (+ PKCE verifier) for the id_token
+func OIDCExchangeCode(p *OIDCProvider, code string, verifier string) (string, error) {
+	cfg := config.AppConfig.OIDC
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", cfg.RedirectURL)
+	form.Set("client_id", cfg.ClientID)
+	form.Set("code_verifier", verifier)
+
+	req, err := http.NewRequest("POST", p.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(cfg.ClientID), url.QueryEscape(cfg.ClientSecret))
+	}
+
+	res, err := oidcHTTP.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	var body struct {
+		IDToken string `json:"id_token"`
+		Error   string `json:"error"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	if res.StatusCode != 200 || body.IDToken == "" {
+		return "", fmt.Errorf("token endpoint: %d %s", res.StatusCode, body.Error)
+	}
+	return body.IDToken, nil
+}
+
+// OIDCVerifyIDToken checks signature, issuer, audience, expiry and nonce of an id_token
+func OIDCVerifyIDToken(p *OIDCProvider, idToken string, nonce string) (*OIDCIdentity, error) {
+	cfg := config.AppConfig.OIDC
+
+	token, err := jwt.Parse(idToken, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, err := oidcKey(p, kid)
+		if err != nil {
+			return nil, err
+		}
+		switch key.(type) {
+		case *rsa.PublicKey:
+			if !strings.HasPrefix(t.Method.Alg(), "RS") {
+				return nil, errors.New("alg doesn't match key")
+			}
+		case ed25519.PublicKey:
+			if t.Method.Alg() != "EdDSA" {
+				return nil, errors.New("alg doesn't match key")
+			}
+		}
+		return key, nil
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "EdDSA"}),
+		jwt.WithIssuer(p.Issuer),
+		jwt.WithAudience(cfg.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	if claims["nonce"] != nonce {
+		return nil, errors.New("nonce mismatch")
+	}
+
+	identity := &OIDCIdentity{Issuer: p.Issuer}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.Name, _ = claims["name"].(string)
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = v
+	case string:
+		identity.EmailVerified = v == "true"
+	}
+	if identity.Subject == "" {
+		return nil, errors.New("id_token has no sub")
+	}
+	return identity, nil
+}
+
+// oidcKey finds the provider key for a kid, refetching the jwks once if it is unknown (key rotation)
+func oidcKey(p *OIDCProvider, kid string) (interface{}, error) {
+	oidcMu.Lock()
+	defer oidcMu.Unlock()
+
+	for attempt := 0; attempt < 2; attempt++ {
+		if oidcKeys == nil || attempt == 1 {
+			keys, err := fetchOIDCKeys(p.JWKSURI)
+			if err != nil {
+				return nil, err
+			}
+			oidcKeys = keys
+		}
+		if key, ok := oidcKeys[kid]; ok {
+			return key, nil
+		}
+		// single key sets often don't bother with kid
+		if kid == "" && len(oidcKeys) == 1 {
+			for _, key := range oidcKeys {
+				return key, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("unknown oidc kid %q", kid)
+}
+
+func fetchOIDCKeys(jwksURI string) (map[string]interface{}, error) {
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+		} `json:"keys"`
+	}
+	if err := oidcGetJSON(jwksURI, &set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]interface{}{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch {
+		case k.Kty == "RSA":
+			n, err1 := base64.RawURLEncoding.DecodeString(k.N)
+			e, err2 := base64.RawURLEncoding.DecodeString(k.E)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case k.Kty == "OKP" && k.Crv == "Ed25519":
+			x, err := base64.RawURLEncoding.DecodeString(k.X)
+			if err != nil || len(x) != ed25519.PublicKeySize {
+				continue
+			}
+			keys[k.Kid] = ed25519.PublicKey(x)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no usable keys in oidc jwks")
+	}
+	return keys, nil
+}
+
+func oidcGetJSON(u string, out interface{}) error {
+	res, err := oidcHTTP.Get(u)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return fmt.Errorf("GET %s: %d", u, res.StatusCode)
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+// GenerateOTP returns a random numeric code of the given length, leading zeros allowed
+func GenerateOTP(digits int) string {
+	code := make([]byte, digits)
+	for i := range code {
+		n, _ := rand.Int(rand.Reader, big.NewInt(10))
+		code[i] = byte('0' + n.Int64())
+	}
+	return string(code)
+}
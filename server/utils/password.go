@@ -0,0 +1,180 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/config"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// argon2id parameters for new hashes, older argon2id hashes with other params get rehashed on login
+const (
+	argonTime    = 1
+	argonMemory  = 64 * 1024 // KiB
+	argonThreads = 4
+	argonKeyLen  = 32
+	argonSaltLen = 16
+)
+
+// HashPassword hashes a password with argon2id in the PHC string format
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, argonSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, argonTime, argonMemory, argonThreads, argonKeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, argonMemory, argonTime, argonThreads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// VerifyPassword checks a password against an argon2id or legacy bcrypt hash.
+// needsRehash is true when the password is right but the hash should be replaced by HashPassword
+func VerifyPassword(hash string, password string) (ok bool, needsRehash bool) {
+	if strings.HasPrefix(hash, "$2") {
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+			return false, false
+		}
+		return true, true
+	}
+
+	var version int
+	var memory uint32
+	var time uint32
+	var threads uint8
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return false, false
+	}
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, false
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return false, false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(want) == 0 {
+		return false, false
+	}
+
+	got := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(want)))
+	if subtle.ConstantTimeCompare(got, want) != 1 {
+		return false, false
+	}
+	stale := memory != argonMemory || time != argonTime || threads != argonThreads || len(want) != argonKeyLen
+	return true, stale
+}
+
+var (
+	blocklistOnce sync.Once
+	blocklist     map[string]bool
+)
+
+// loadBlocklist reads the common passwords file once, a missing file only disables that check
+func loadBlocklist() {
+	blocklist = map[string]bool{}
+	file := config.AppConfig.Passwords.BlocklistFile
+	if file == "" {
+		return
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		fmt.Println("⚠️ password blocklist not loaded:", err)
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if line != "" && !strings.HasPrefix(line, "#") {
+			blocklist[line] = true
+		}
+	}
+}
+
+// CheckPasswordPolicy returns a readable error when a new password breaks the configured policy
+func CheckPasswordPolicy(password string) error {
+	policy := config.AppConfig.Passwords
+
+	if len([]rune(password)) < policy.MinLength {
+		return fmt.Errorf("password must be at least %d characters", policy.MinLength)
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if policy.RequireUpper && !upper {
+		return errors.New("password must contain an uppercase letter")
+	}
+	if policy.RequireLower && !lower {
+		return errors.New("password must contain a lowercase letter")
+	}
+	if policy.RequireDigit && !digit {
+		return errors.New("password must contain a digit")
+	}
+	if policy.RequireSymbol && !symbol {
+		return errors.New("password must contain a symbol")
+	}
+
+	blocklistOnce.Do(loadBlocklist)
+	if blocklist[strings.ToLower(password)] {
+		return errors.New("password is too common, pick another one")
+	}
+	return nil
+}
+
+// PasswordReused reports whether password matches the current hash or one in history
+func PasswordReused(password string, currentHash string, history []string) bool {
+	if config.AppConfig.Passwords.HistorySize <= 0 {
+		return false
+	}
+	if ok, _ := VerifyPassword(currentHash, password); ok {
+		return true
+	}
+	for _, h := range history {
+		if ok, _ := VerifyPassword(h, password); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// NextPasswordHistory pushes the hash being replaced to the front of history,
+// keeping HistorySize-1 old hashes since the new password is the other one
+func NextPasswordHistory(replacedHash string, history []string) []string {
+	keep := config.AppConfig.Passwords.HistorySize - 1
+	if keep <= 0 {
+		return []string{}
+	}
+	next := append([]string{replacedHash}, history...)
+	if len(next) > keep {
+		next = next[:keep]
+	}
+	return next
+}
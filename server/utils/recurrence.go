@@ -0,0 +1,242 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
+	"github.com/teambition/rrule-go"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// limits on recurring series, a series always ends
+const (
+	MaxOccurrences = 500
+	MaxSeriesSpan  = 2 * 366 * 24 * time.Hour
+)
+
+// events repeat at most daily, the time of day comes from startAt
+var seriesFreqs = []rrule.Frequency{rrule.DAILY, rrule.WEEKLY, rrule.MONTHLY, rrule.YEARLY}
+
+// UNTIL=20261231 is read as the end of that day, not its midnight
+var untilDate = regexp.MustCompile(`UNTIL=(\d{8})(;|$)`)
+
+// Series is a validated RRULE anchored on a schedule, with every occurrence worked out.
+// series are bounded so keeping all the starts is cheap
+type Series struct {
+	Rule     string // normalized, without DTSTART
+	Schedule Schedule
+	option   rrule.ROption
+	starts   []time.Time // UTC, cancelled ones included
+}
+
+// ParseSeries checks rule for schedule: DAILY or slower, ended by COUNT or UNTIL, at most
+// MaxOccurrences within MaxSeriesSpan, and schedule.StartAt has to be its first occurrence
+func ParseSeries(rule string, schedule Schedule) (*Series, error) {
+	rule = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(rule)), "RRULE:")
+	if rule == "" {
+		return nil, errors.New("rrule is empty")
+	}
+	if strings.Contains(rule, "DTSTART") || strings.Contains(rule, "\n") {
+		return nil, errors.New("rrule must not have a DTSTART, the series starts at startAt")
+	}
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not an IANA timezone (e.g. Asia/Kolkata)", schedule.Timezone)
+	}
+
+	option, err := rrule.StrToROptionInLocation(untilDate.ReplaceAllString(rule, "UNTIL=${1}T235959$2"), loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rrule: %v", err)
+	}
+	if !slices.Contains(seriesFreqs, option.Freq) {
+		return nil, errors.New("FREQ must be DAILY, WEEKLY, MONTHLY or YEARLY")
+	}
+	if len(option.Byhour) > 0 || len(option.Byminute) > 0 || len(option.Bysecond) > 0 {
+		return nil, errors.New("BYHOUR, BYMINUTE and BYSECOND aren't supported, the time of day comes from startAt")
+	}
+	if option.Count == 0 && option.Until.IsZero() {
+		return nil, fmt.Errorf("rrule needs COUNT or UNTIL, a series has at most %d occurrences", MaxOccurrences)
+	}
+
+	option.Dtstart = schedule.StartAt.In(loc)
+	r, err := rrule.NewRRule(*option)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rrule: %v", err)
+	}
+
+	starts := []time.Time{}
+	last := schedule.StartAt.Add(MaxSeriesSpan)
+	next := r.Iterator()
+	for t, ok := next(); ok; t, ok = next() {
+		if len(starts) == MaxOccurrences || t.After(last) {
+			return nil, fmt.Errorf("a series has at most %d occurrences within two years", MaxOccurrences)
+		}
+		starts = append(starts, t.UTC())
+	}
+	if len(starts) == 0 || !starts[0].Equal(schedule.StartAt) {
+		return nil, errors.New("startAt must be the first occurrence of the rrule")
+	}
+
+	return &Series{Rule: option.RRuleString(), Schedule: schedule, option: *option, starts: starts}, nil
+}
+
+// SeriesOf is the series of a stored recurring event
+func SeriesOf(e *models.Event) (*Series, error) {
+	if e.Recurrence == nil {
+		return nil, errors.New("event doesn't repeat")
+	}
+	return ParseSeries(e.Recurrence.RRule, Schedule{StartAt: e.StartAt, EndAt: e.EndAt, Timezone: e.Timezone})
+}
+
+// Len is the number of occurrences, cancelled ones included
+func (s *Series) Len() int {
+	return len(s.starts)
+}
+
+// Start is the original start of occurrence i
+func (s *Series) Start(i int) time.Time {
+	return s.starts[i]
+}
+
+// Index finds the occurrence starting at t, -1 when there is none
+func (s *Series) Index(t time.Time) int {
+	i := sort.Search(len(s.starts), func(i int) bool { return !s.starts[i].Before(t) })
+	if i < len(s.starts) && s.starts[i].Equal(t) {
+		return i
+	}
+	return -1
+}
+
+// Duration is how long every occurrence lasts
+func (s *Series) Duration() time.Duration {
+	return s.Schedule.EndAt.Sub(s.Schedule.StartAt)
+}
+
+// Before is the series cut so it stops before occurrence i, i must be at least 1
+func (s *Series) Before(i int) (*Series, error) {
+	option := s.option
+	option.Count = 0
+	option.Until = s.starts[i].Add(-time.Second)
+	return ParseSeries(option.RRuleString(), s.Schedule)
+}
+
+// From is the rest of the series from occurrence i on, moved to schedule.
+// COUNT drops the occurrences before i, UNTIL stays
+func (s *Series) From(i int, schedule Schedule) (*Series, error) {
+	option := s.option
+	if option.Count > 0 {
+		option.Count -= i
+	}
+	return ParseSeries(option.RRuleString(), schedule)
+}
+
+// Expand lists the occurrences overlapping [from, to), soonest first. zero from/to leave that side open.
+// cancelled ones are left out, overridden ones carry their own values. base holds the series' values
+func (s *Series) Expand(rec *models.Recurrence, base models.Occurrence, from time.Time, to time.Time) []models.Occurrence {
+	out := []models.Occurrence{}
+	for _, start := range s.starts {
+		if slices.ContainsFunc(rec.ExDates, start.Equal) {
+			continue
+		}
+		o := base
+		o.OccurrenceId = start.Format(time.RFC3339)
+		o.StartAt = start
+		o.EndAt = start.Add(s.Duration())
+		if ov := FindOverride(rec, start); ov != nil {
+			applyOverride(&o, ov)
+		}
+		if (to.IsZero() || o.StartAt.Before(to)) && (from.IsZero() || o.EndAt.After(from)) {
+			out = append(out, o)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].StartAt.Before(out[j].StartAt) })
+	return out
+}
+
+// EndAt is when the last occurrence that still happens ends, the first one's end when all are cancelled
+func (s *Series) EndAt(rec *models.Recurrence) time.Time {
+	end := s.Schedule.EndAt
+	for _, o := range s.Expand(rec, models.Occurrence{}, time.Time{}, time.Time{}) {
+		if o.EndAt.After(end) {
+			end = o.EndAt
+		}
+	}
+	return end
+}
+
+// Occurrences lists the dates of e overlapping [from, to), a single event has at most one
+func Occurrences(e *models.Event, from time.Time, to time.Time) []models.Occurrence {
+	base := models.Occurrence{
+		EventName:        e.EventName,
+		EventDescription: e.EventDescription,
+		Location:         e.Location,
+		Coordinates:      e.Coordinates,
+	}
+	if e.Recurrence == nil {
+		if e.StartAt.Before(to) && e.EndAt.After(from) {
+			base.OccurrenceId = e.StartAt.UTC().Format(time.RFC3339)
+			base.StartAt = e.StartAt
+			base.EndAt = e.EndAt
+			return []models.Occurrence{base}
+		}
+		return []models.Occurrence{}
+	}
+
+	series, err := SeriesOf(e)
+	if err != nil {
+		// stored rules were checked on write, this only happens on hand edited data
+		fmt.Println("⚠️ bad rrule on event", e.ID.Hex(), err)
+		return []models.Occurrence{}
+	}
+	return series.Expand(e.Recurrence, base, from, to)
+}
+
+// FindOverride is the override of the occurrence starting at start, nil when it has none
+func FindOverride(rec *models.Recurrence, start time.Time) *models.OccurrenceOverride {
+	for i := range rec.Overrides {
+		if rec.Overrides[i].OriginalStart.Equal(start) {
+			return &rec.Overrides[i]
+		}
+	}
+	return nil
+}
+
+func applyOverride(o *models.Occurrence, ov *models.OccurrenceOverride) {
+	o.Overridden = true
+	o.StartAt = ov.StartAt
+	o.EndAt = ov.EndAt
+	if ov.EventName != "" {
+		o.EventName = ov.EventName
+	}
+	if ov.EventDescription != "" {
+		o.EventDescription = ov.EventDescription
+	}
+	if ov.Location != "" {
+		o.Location = ov.Location
+		o.Coordinates = ov.Coordinates
+	}
+}
+
+// ParseOccurrenceId reads an occurrence id, the original start in RFC3339
+func ParseOccurrenceId(id string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, id)
+	if err != nil {
+		return t, fmt.Errorf("%q is not an occurrence id, use its original start in RFC3339", id)
+	}
+	return t.UTC(), nil
+}
+
+// EndsAfter goes under $or, it matches what is still on after t:
+// single events and functions by endAt, series by their last occurrence
+func EndsAfter(t time.Time) bson.A {
+	return bson.A{
+		bson.M{"endAt": bson.M{"$gt": t}},
+		bson.M{"recurrence.seriesEndAt": bson.M{"$gt": t}},
+	}
+}
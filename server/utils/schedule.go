@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	// ship the tz database with the binary, slim containers don't have /usr/share/zoneinfo
+	_ "time/tzdata"
+)
+
+// longest an event or function may run
+const MaxScheduleLength = 30 * 24 * time.Hour
+
+// local wall clock layouts accepted besides RFC3339, read in the given timezone
+var localLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+// Schedule is a validated start/end pair, stored in UTC with the IANA zone it was planned in
+type Schedule struct {
+	StartAt  time.Time
+	EndAt    time.Time
+	Timezone string
+}
+
+// ParseScheduleTime reads an RFC3339 time, or a wall clock time in loc
+func ParseScheduleTime(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range localLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a valid time, use RFC3339 or YYYY-MM-DDTHH:MM", value)
+}
+
+// ParseSchedule validates start, end and timezone. times without an offset are local to timezone
+func ParseSchedule(start string, end string, timezone string) (Schedule, error) {
+	if start == "" || end == "" || timezone == "" {
+		return Schedule{}, errors.New("startAt, endAt and timezone are required")
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil || timezone == "Local" {
+		return Schedule{}, fmt.Errorf("%q is not an IANA timezone (e.g. Asia/Kolkata)", timezone)
+	}
+
+	startAt, err := ParseScheduleTime(start, loc)
+	if err != nil {
+		return Schedule{}, err
+	}
+	endAt, err := ParseScheduleTime(end, loc)
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	if !endAt.After(startAt) {
+		return Schedule{}, errors.New("endAt must be after startAt")
+	}
+	if endAt.Sub(startAt) > MaxScheduleLength {
+		return Schedule{}, errors.New("an event can last at most 30 days")
+	}
+
+	return Schedule{StartAt: startAt.UTC(), EndAt: endAt.UTC(), Timezone: loc.String()}, nil
+}
+
+// ParseRange reads the optional from/to query values for range filters, a bare date means midnight UTC
+func ParseRange(from string, to string) (time.Time, time.Time, error) {
+	var fromT, toT time.Time
+	var err error
+	if from != "" {
+		if fromT, err = parseRangeTime(from); err != nil {
+			return fromT, toT, fmt.Errorf("from: %v", err)
+		}
+	}
+	if to != "" {
+		if toT, err = parseRangeTime(to); err != nil {
+			return fromT, toT, fmt.Errorf("to: %v", err)
+		}
+	}
+	if !fromT.IsZero() && !toT.IsZero() && !toT.After(fromT) {
+		return fromT, toT, errors.New("to must be after from")
+	}
+	return fromT, toT, nil
+}
+
+func parseRangeTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not RFC3339 or YYYY-MM-DD", value)
+}
@@ -0,0 +1,265 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// search limits
+const (
+	searchMinQuery = 2
+	searchMaxQuery = 100
+	searchMaxLimit = 50
+	snippetRadius  = 40 // characters kept on each side of the first match
+)
+
+// text fields per kind, name first, heavier weight ranks higher
+var (
+	eventSearchFields    = bson.D{{Key: "eventname", Value: 10}, {Key: "location", Value: 4}, {Key: "eventdesc", Value: 2}}
+	functionSearchFields = bson.D{{Key: "funcname", Value: 10}, {Key: "location", Value: 4}, {Key: "funcdes", Value: 2}}
+)
+
+// SearchTextIndexes creates the one text index mongo allows per collection
+func SearchTextIndexes(ctx context.Context, events *mongo.Collection, functions *mongo.Collection) {
+	for coll, fields := range map[*mongo.Collection]bson.D{
+		events:    eventSearchFields,
+		functions: functionSearchFields,
+	} {
+		keys := bson.D{}
+		weights := bson.D{}
+		for _, f := range fields {
+			keys = append(keys, bson.E{Key: f.Key, Value: "text"})
+			weights = append(weights, f)
+		}
+		opts := options.Index().SetWeights(weights).SetName(coll.Name() + "_text").SetDefaultLanguage("english")
+		if _, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: keys, Options: opts}); err != nil {
+			fmt.Println("⚠️ couldn't create text index on", coll.Name(), err)
+		}
+	}
+}
+
+// SearchHit is one result, with the fields that matched cut down to a highlighted snippet
+type SearchHit[T any] struct {
+	Item       T                 `json:"item"`
+	Score      float64           `json:"score,omitempty"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+// SearchQuery is a parsed ?q=&kind=&page=&limit=
+type SearchQuery struct {
+	Text  string
+	Terms []string // words and phrases to highlight, negated ones left out
+	Kind  string
+	Page  int
+	Limit int
+}
+
+var searchPhrase = regexp.MustCompile(`"([^"]+)"`)
+
+// ParseSearchQuery reads the search params, answers 400 itself when they are off
+func ParseSearchQuery(c *gin.Context) (*SearchQuery, bool) {
+	q := strings.TrimSpace(c.Query("q"))
+	if len(q) < searchMinQuery || len(q) > searchMaxQuery {
+		c.JSON(400, gin.H{"msg": "q must be 2 to 100 characters"})
+		return nil, false
+	}
+
+	kind := c.DefaultQuery("kind", "all")
+	if kind != "all" && kind != "events" && kind != "functions" {
+		c.JSON(400, gin.H{"msg": "kind must be events, functions or all"})
+		return nil, false
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	if limit > searchMaxLimit {
+		limit = searchMaxLimit
+	}
+
+	// "quoted phrases" stay whole, the rest splits into words
+	terms := []string{}
+	for _, m := range searchPhrase.FindAllStringSubmatch(q, -1) {
+		terms = append(terms, strings.TrimSpace(m[1]))
+	}
+	for _, word := range strings.Fields(searchPhrase.ReplaceAllString(q, " ")) {
+		if strings.HasPrefix(word, "-") {
+			continue
+		}
+		word = strings.Trim(word, `"`)
+		if word != "" {
+			terms = append(terms, word)
+		}
+	}
+
+	return &SearchQuery{Text: q, Terms: terms, Kind: kind, Page: page, Limit: limit}, true
+}
+
+// Search runs query on both kinds within scopeFilter and builds the response,
+// hits are turned into what the caller may show by toEvent and toFunction
+func Search[E any, F any](ctx context.Context, query *SearchQuery, scope string, scopeFilter bson.M, events *mongo.Collection, functions *mongo.Collection, toEvent func(models.Event) E, toFunction func(models.Function) F) (gin.H, error) {
+	response := gin.H{"msg": "Search results🔎", "q": query.Text, "scope": scope, "page": query.Page, "limit": query.Limit}
+
+	if query.Kind != "functions" {
+		hits, total, match, err := searchCollection[models.Event](ctx, events, scopeFilter, query, eventSearchFields)
+		if err != nil {
+			return nil, err
+		}
+		response["events"] = mapHits(hits, toEvent)
+		response["eventsTotal"] = total
+		response["eventsMatch"] = match
+	}
+
+	if query.Kind != "events" {
+		hits, total, match, err := searchCollection[models.Function](ctx, functions, scopeFilter, query, functionSearchFields)
+		if err != nil {
+			return nil, err
+		}
+		response["functions"] = mapHits(hits, toFunction)
+		response["functionsTotal"] = total
+		response["functionsMatch"] = match
+	}
+
+	return response, nil
+}
+
+// searchCollection runs a ranked $text search, and when that finds nothing a name prefix
+// search, so "birth" still finds "Birthday". match says which one answered
+func searchCollection[T any](ctx context.Context, coll *mongo.Collection, scopeFilter bson.M, query *SearchQuery, fields bson.D) ([]SearchHit[T], int64, string, error) {
+	skip := int64((query.Page - 1) * query.Limit)
+
+	filter := bson.M{"$text": bson.M{"$search": query.Text}}
+	for k, v := range scopeFilter {
+		filter[k] = v
+	}
+	opts := options.Find().
+		SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "_id", Value: 1}}).
+		SetSkip(skip).SetLimit(int64(query.Limit))
+
+	hits, total, err := findHits[T](ctx, coll, filter, opts, query, fields)
+	if err != nil || total > 0 || len(query.Terms) == 0 {
+		return hits, total, "text", err
+	}
+
+	// prefix fallback, anchored and on the name only so it stays cheap on the public endpoint
+	name := fields[:1]
+	filter = bson.M{name[0].Key: bson.M{"$regex": "^" + regexp.QuoteMeta(strings.Join(query.Terms, " ")), "$options": "i"}}
+	for k, v := range scopeFilter {
+		filter[k] = v
+	}
+	opts = options.Find().SetSort(bson.D{{Key: "startAt", Value: 1}, {Key: "_id", Value: 1}}).SetSkip(skip).SetLimit(int64(query.Limit))
+
+	hits, total, err = findHits[T](ctx, coll, filter, opts, query, name)
+	return hits, total, "prefix", err
+}
+
+func findHits[T any](ctx context.Context, coll *mongo.Collection, filter bson.M, opts *options.FindOptions, query *SearchQuery, fields bson.D) ([]SearchHit[T], int64, error) {
+	total, err := coll.CountDocuments(ctx, filter)
+	if err != nil || total == 0 {
+		return []SearchHit[T]{}, total, err
+	}
+
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	hits := []SearchHit[T]{}
+	for cursor.Next(ctx) {
+		var item T
+		if err := cursor.Decode(&item); err != nil {
+			return nil, 0, err
+		}
+		hit := SearchHit[T]{Item: item, Highlights: map[string]string{}}
+		hit.Score, _ = cursor.Current.Lookup("score").DoubleOK()
+		for _, f := range fields {
+			text, _ := cursor.Current.Lookup(f.Key).StringValueOK()
+			if snippet, ok := highlight(text, query.Terms); ok {
+				hit.Highlights[f.Key] = snippet
+			}
+		}
+		hits = append(hits, hit)
+	}
+	return hits, total, cursor.Err()
+}
+
+// highlight cuts text down around the first match and wraps every match in <mark>,
+// the rest is html escaped so the snippet is safe to render
+func highlight(text string, terms []string) (string, bool) {
+	if text == "" || len(terms) == 0 {
+		return "", false
+	}
+
+	parts := make([]string, 0, len(terms))
+	for _, t := range terms {
+		parts = append(parts, regexp.QuoteMeta(t))
+	}
+	// a term matches the start of a word and runs to its end, so "birth" marks "Birthday"
+	re, err := regexp.Compile(`(?i)\b(?:` + strings.Join(parts, "|") + `)\w*`)
+	if err != nil {
+		return "", false
+	}
+
+	matches := re.FindAllStringIndex(text, -1)
+	if len(matches) == 0 {
+		return "", false
+	}
+
+	start := max(0, matches[0][0]-snippetRadius)
+	end := min(len(text), matches[0][1]+snippetRadius)
+	// don't cut a utf-8 character in half
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end++
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, m := range matches {
+		if m[0] < start {
+			continue
+		}
+		if m[1] > end {
+			break
+		}
+		b.WriteString(html.EscapeString(text[pos:m[0]]))
+		b.WriteString("<mark>" + html.EscapeString(text[m[0]:m[1]]) + "</mark>")
+		pos = m[1]
+	}
+	b.WriteString(html.EscapeString(text[pos:end]))
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String(), true
+}
+
+func mapHits[T any, U any](hits []SearchHit[T], convert func(T) U) []SearchHit[U] {
+	out := make([]SearchHit[U], 0, len(hits))
+	for _, h := range hits {
+		out = append(out, SearchHit[U]{Item: convert(h.Item), Score: h.Score, Highlights: h.Highlights})
+	}
+	return out
+}
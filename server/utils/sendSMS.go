@@ -2,6 +2,8 @@ package utils
 
 import (
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/config"
 	"github.com/twilio/twilio-go"
@@ -12,9 +14,26 @@ type SMSData struct {
 	From string
 	To string
 	Body string
+	SentAt time.Time
 }
 
+// fake sms sink, used when config.AppConfig.Phone.FakeSink is on
+var (
+	fakeOutbox   []SMSData
+	fakeOutboxMu sync.Mutex
+)
+
 func SendSMS(data SMSData) error {
+	// local testing, no twilio
+	if config.AppConfig.Phone.FakeSink {
+		data.SentAt = time.Now()
+		fakeOutboxMu.Lock()
+		fakeOutbox = append(fakeOutbox, data)
+		fakeOutboxMu.Unlock()
+		fmt.Printf("📱 [FAKE SMS] to %s: %s\n", data.To, data.Body)
+		return nil
+	}
+
 	// create client
 	client := twilio.NewRestClientWithParams(twilio.ClientParams{
 		Username: config.AppConfig.Phone.Sid,
@@ -30,7 +49,22 @@ func SendSMS(data SMSData) error {
 
 	if err != nil {
 		fmt.Println("couldn't send sms", err)
+		return err
 	}
 
 	return  nil
-}
\ No newline at end of file
+}
+
+// FakeSMSOutbox returns the sms kept by the fake sink, optionally only the ones sent to one number
+func FakeSMSOutbox(to string) []SMSData {
+	fakeOutboxMu.Lock()
+	defer fakeOutboxMu.Unlock()
+
+	out := []SMSData{}
+	for _, sms := range fakeOutbox {
+		if to == "" || sms.To == to {
+			out = append(out, sms)
+		}
+	}
+	return out
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"context"
+	"time"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RevokeSessions marks the sessions matching filter as revoked and denylists their sid,
+// so access tokens already handed to those devices stop working too
+func RevokeSessions(ctx context.Context, sessions *mongo.Collection, filter bson.M) (int64, error) {
+	filter["revoked"] = false
+
+	cursor, err := sessions.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return 0, err
+	}
+	var found []models.Session
+	if err := cursor.All(ctx, &found); err != nil {
+		return 0, err
+	}
+	if len(found) == 0 {
+		return 0, nil
+	}
+	ids := make([]primitive.ObjectID, 0, len(found))
+	for _, s := range found {
+		ids = append(ids, s.ID)
+	}
+
+	res, err := sessions.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}, "revoked": false}, bson.M{
+		"$set": bson.M{
+			"revoked":      true,
+			"revokedAt":    time.Now(),
+			"refreshToken": "",
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		if err := DenySession(id.Hex()); err != nil {
+			return res.ModifiedCount, err
+		}
+	}
+	return res.ModifiedCount, nil
+}
+
+// RevokeAccountAccess kills every session and access token of an account
+func RevokeAccountAccess(ctx context.Context, sessions *mongo.Collection, accountId primitive.ObjectID, accountType string) error {
+	if _, err := RevokeSessions(ctx, sessions, bson.M{"accountId": accountId, "accountType": accountType}); err != nil {
+		return err
+	}
+	return DenyAccount(accountId.Hex())
+}
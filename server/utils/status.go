@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/models"
+)
+
+// statusTransitions is the status state machine, Completed is final
+var statusTransitions = map[string][]string{
+	models.StatusUpcoming:  {models.StatusCancelled, models.StatusCompleted},
+	models.StatusCancelled: {models.StatusUpcoming},
+	models.StatusCompleted: {},
+}
+
+// IsStatus reports whether status is one the state machine knows
+func IsStatus(status string) bool {
+	_, ok := statusTransitions[status]
+	return ok
+}
+
+// CheckStatusTransition returns an error unless from -> to is allowed
+func CheckStatusTransition(from string, to string) error {
+	if !IsStatus(to) {
+		return fmt.Errorf("%q is not a valid status, use Upcoming, Cancelled or Completed", to)
+	}
+	// documents from before the state machine may hold anything, let them pick a valid status
+	if !IsStatus(from) {
+		return nil
+	}
+	for _, next := range statusTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("status can't change from %s to %s", from, to)
+}
@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AbdulRahman-04/GoProjects/EventManagement/server/config"
+	"github.com/skip2/go-qrcode"
+)
+
+// TicketCodePrefix marks a ticket code and its format version
+const TicketCodePrefix = "TKT1."
+
+// ticketMasterKey derives a signing key per event, so each event can hand out its own public key
+var ticketMasterKey []byte
+
+var ErrTicketKeyMissing = errors.New("ticket signing key not loaded")
+
+// TicketPayload is what a ticket's QR code carries, signed with the event's key
+type TicketPayload struct {
+	TicketId string `json:"tid"`
+	EventId  string `json:"eid"`
+	Type     string `json:"typ,omitempty"`
+	IssuedAt int64  `json:"iat"`
+}
+
+// LoadTicketKey reads the hex master key from the configured file
+func LoadTicketKey() error {
+	file := config.AppConfig.Tickets.MasterKeyFile
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("ticket key %s: %w (run: go run . ticket-genkey)", file, err)
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(raw)))
+	if err != nil || len(key) != 32 {
+		return fmt.Errorf("ticket key %s must be 32 bytes of hex", file)
+	}
+	ticketMasterKey = key
+	fmt.Println("✅ Loaded ticket signing key")
+	return nil
+}
+
+// GenerateTicketKey writes a new random master key, it refuses to overwrite one
+// because every ticket already issued would stop verifying
+func GenerateTicketKey() (string, error) {
+	file := config.AppConfig.Tickets.MasterKeyFile
+	if _, err := os.Stat(file); err == nil {
+		return "", fmt.Errorf("%s already exists", file)
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return "", err
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return file, os.WriteFile(file, []byte(hex.EncodeToString(key)+"\n"), 0600)
+}
+
+// eventTicketKey is the Ed25519 key of one event, derived from the master key
+func eventTicketKey(eventId string) (ed25519.PrivateKey, error) {
+	if ticketMasterKey == nil {
+		return nil, ErrTicketKeyMissing
+	}
+	mac := hmac.New(sha256.New, ticketMasterKey)
+	mac.Write([]byte("ticket-signing:" + eventId))
+	return ed25519.NewKeyFromSeed(mac.Sum(nil)), nil
+}
+
+// EventTicketPublicKey returns the key scanners use to check an event's tickets offline
+func EventTicketPublicKey(eventId string) (ed25519.PublicKey, error) {
+	priv, err := eventTicketKey(eventId)
+	if err != nil {
+		return nil, err
+	}
+	return priv.Public().(ed25519.PublicKey), nil
+}
+
+// EventTicketPublicKeyPEM is the same key as a PKIX pem block
+func EventTicketPublicKeyPEM(eventId string) (string, error) {
+	pub, err := EventTicketPublicKey(eventId)
+	if err != nil {
+		return "", err
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+// SignTicket builds the ticket code: TKT1.<base64url payload>.<base64url signature>
+func SignTicket(payload TicketPayload) (string, error) {
+	priv, err := eventTicketKey(payload.EventId)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	body := base64.RawURLEncoding.EncodeToString(data)
+	sig := ed25519.Sign(priv, []byte(TicketCodePrefix+body))
+	return TicketCodePrefix + body + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// VerifyTicket checks a ticket code against the event's key and returns its payload
+func VerifyTicket(code string, eventId string) (*TicketPayload, error) {
+	invalid := errors.New("invalid ticket")
+
+	if !strings.HasPrefix(code, TicketCodePrefix) {
+		return nil, invalid
+	}
+	body, sigPart, ok := strings.Cut(strings.TrimPrefix(code, TicketCodePrefix), ".")
+	if !ok {
+		return nil, invalid
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(sigPart)
+	if err != nil {
+		return nil, invalid
+	}
+
+	pub, err := EventTicketPublicKey(eventId)
+	if err != nil {
+		return nil, err
+	}
+	if !ed25519.Verify(pub, []byte(TicketCodePrefix+body), sig) {
+		return nil, invalid
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return nil, invalid
+	}
+	var payload TicketPayload
+	if err := json.Unmarshal(data, &payload); err != nil || payload.EventId != eventId {
+		return nil, invalid
+	}
+	return &payload, nil
+}
+
+// TicketQR renders a ticket code as a QR code png
+func TicketQR(code string, size int) ([]byte, error) {
+	return qrcode.Encode(code, qrcode.Medium, size)
+}
+
+// NewTicketId is a random id, unique per ticket
+func NewTicketId() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
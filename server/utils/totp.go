@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RFC 6238 defaults, what every authenticator app expects
+const (
+	totpDigits = 6
+	totpPeriod = 30
+	totpSkew   = 1 // accept one step before/after for clock drift
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a new 160 bit base32 secret
+func GenerateTOTPSecret() string {
+	d := make([]byte, 20)
+	_, _ = rand.Read(d)
+	return totpEncoding.EncodeToString(d)
+}
+
+// TOTPCode computes the code of the time step containing t
+func TOTPCode(secret string, t time.Time) (string, error) {
+	return totpCodeAt(secret, uint64(t.Unix()/totpPeriod))
+}
+
+func totpCodeAt(secret string, step uint64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil {
+		return "", err
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], step)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// dynamic truncation (RFC 4226 section 5.3)
+	offset := sum[len(sum)-1] & 0x0f
+	bin := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, bin%1000000), nil
+}
+
+// VerifyTOTP checks a code against the current step and its neighbours,
+// returns the matched step so callers can refuse replays of the same step
+func VerifyTOTP(secret string, code string) (uint64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	now := uint64(time.Now().Unix() / totpPeriod)
+	for i := -totpSkew; i <= totpSkew; i++ {
+		step := now + uint64(i)
+		expected, err := totpCodeAt(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// TOTPProvisioningURI builds the otpauth:// uri that authenticator apps scan as a QR code
+func TOTPProvisioningURI(secret string, issuer string, account string) string {
+	label := url.PathEscape(issuer + ":" + account)
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(totpDigits))
+	q.Set("period", fmt.Sprint(totpPeriod))
+	return "otpauth://totp/" + label + "?" + q.Encode()
+}
+
+// GenerateRecoveryCodes returns n one time codes to show the user and their hashes to store
+func GenerateRecoveryCodes(n int) ([]string, []string) {
+	codes := make([]string, 0, n)
+	hashes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		d := make([]byte, 5)
+		_, _ = rand.Read(d)
+		raw := hex.EncodeToString(d)
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, HashRecoveryCode(raw))
+	}
+	return codes, hashes
+}
+
+// HashRecoveryCode normalises a typed recovery code (case, dashes, spaces) before hashing
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return HashToken(code)
+}
+
+// ClaimTOTPStep makes a code single use: the same time step can't be used twice by one account
+func ClaimTOTPStep(accountId string, step uint64) (bool, error) {
+	key := fmt.Sprintf("mfa:step:%s:%d", accountId, step)
+	return RedisClient.SetNX(Ctx, key, "1", time.Duration((2*totpSkew+1)*totpPeriod)*time.Second).Result()
+}